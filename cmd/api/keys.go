@@ -0,0 +1,33 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/maxdikun/users-api/internal/config"
+	"github.com/maxdikun/users-api/internal/keys"
+)
+
+// newKeyRotator loads the signing keys from the configured source. Retired
+// keys stay published for one access token lifetime, so every token they
+// signed can still be verified.
+func newKeyRotator(logger *slog.Logger, cfg config.Sessions) (*keys.Rotator, *keys.Ring, error) {
+	var source keys.Source
+	switch {
+	case cfg.SigningKeyFile != "":
+		source = keys.File(cfg.SigningKeyFile)
+	case cfg.SigningKeyDir != "":
+		source = keys.Dir(cfg.SigningKeyDir)
+	default:
+		logger.Warn("Using ephemeral signing keys; access tokens will not survive a restart or verify on other instances")
+	}
+
+	return keys.NewRotator(
+		logger,
+		source,
+		cfg.AccessTokenDuration,
+		cfg.SigningAlgorithm,
+		cfg.KeyRotationInterval,
+		cfg.KeyReloadInterval,
+		cfg.KeyPublishDelay,
+	)
+}
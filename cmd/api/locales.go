@@ -0,0 +1,19 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+
+	"github.com/maxdikun/users-api/internal/config"
+	"github.com/maxdikun/users-api/internal/i18n"
+)
+
+// newCatalog loads the message catalogs responses are translated with.
+func newCatalog(cfg config.Locales) (*i18n.Catalog, error) {
+	var catalogFS fs.FS = i18n.BuiltinCatalogs()
+	if cfg.CatalogDir != "" {
+		catalogFS = os.DirFS(cfg.CatalogDir)
+	}
+
+	return i18n.NewCatalog(catalogFS, cfg.Default)
+}
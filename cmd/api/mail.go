@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io/fs"
+	"log/slog"
+	"os"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/config"
+	"github.com/maxdikun/users-api/internal/mail"
+)
+
+// newMailer creates the configured mail driver together with the templates
+// mail is rendered from.
+func newMailer(logger *slog.Logger, cfg config.Mail) (ports.Mailer, *mail.Templates, error) {
+	var templateFS fs.FS = mail.BuiltinTemplates()
+	if cfg.TemplateDir != "" {
+		templateFS = os.DirFS(cfg.TemplateDir)
+	}
+
+	templates, err := mail.NewTemplates(templateFS, cfg.DefaultLocale)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch cfg.Driver {
+	case config.MailDriverSMTP:
+		mailer, err := mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			Security: cfg.SMTPSecurity,
+			From:     cfg.From,
+		})
+		return mailer, templates, err
+	case config.MailDriverFile:
+		mailer, err := mail.NewFileMailer(cfg.FileDir, cfg.From)
+		return mailer, templates, err
+	default:
+		return mail.NewLogMailer(logger), templates, nil
+	}
+}
@@ -1,7 +1,270 @@
 package main
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/maxdikun/users-api/internal/application"
+	"github.com/maxdikun/users-api/internal/config"
+	"github.com/maxdikun/users-api/internal/events"
+	"github.com/maxdikun/users-api/internal/keys"
+	"github.com/maxdikun/users-api/internal/transport/rest"
+)
 
 func main() {
-	fmt.Println("Hello, world!")
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: cfg.LogLevel}))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, logger, cfg); err != nil {
+		logger.Error("Application terminated", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, logger *slog.Logger, cfg config.Config) error {
+	store, err := newStorage(ctx, logger, cfg)
+	if err != nil {
+		return err
+	}
+	defer store.close()
+
+	keyRotator, signingKeys, err := newKeyRotator(logger, cfg.Sessions)
+	if err != nil {
+		return err
+	}
+
+	passwordHasher, err := newPasswordHasher(cfg.Passwords)
+	if err != nil {
+		return err
+	}
+	passwordFactory, err := newPasswordFactory(logger, cfg.Passwords, passwordHasher)
+	if err != nil {
+		return err
+	}
+
+	mailer, mailTemplates, err := newMailer(logger, cfg.Mail)
+	if err != nil {
+		return err
+	}
+
+	catalog, err := newCatalog(cfg.Locales)
+	if err != nil {
+		return err
+	}
+
+	// Each endpoint that mails has a queue of its own, so that a flood of
+	// resend or reset requests cannot push out the mail of the others.
+	newTaskQueue := func(name string) *application.TaskQueue {
+		return application.NewTaskQueue(
+			logger.With(slog.String("queue", name)), cfg.Mail.QueueSize, cfg.Mail.Workers,
+		)
+	}
+	registrationQueue := newTaskQueue("registration")
+	confirmationQueue := newTaskQueue("email_confirmation")
+	resetQueue := newTaskQueue("password_reset")
+	taskQueues := []*application.TaskQueue{registrationQueue, confirmationQueue, resetQueue}
+
+	confirmationPolicy := application.EmailConfirmationPolicy(cfg.EmailConfirmation.Policy)
+	confirmationService := application.NewEmailConfirmationService(
+		logger,
+		store.userFinder,
+		store.userUpdater,
+		store.actionTokenAppender,
+		store.actionTokenConsumer,
+		store.actionCooldownClaimer,
+		mailer,
+		mailTemplates,
+		confirmationQueue,
+		application.EmailConfirmationConfig{
+			TokenDuration:  cfg.EmailConfirmation.TokenDuration,
+			ResendCooldown: cfg.EmailConfirmation.ResendCooldown,
+			LinkTemplate:   cfg.EmailConfirmation.LinkTemplate,
+			TokenPepper:    []byte(cfg.Sessions.RefreshTokenPepper),
+		},
+	)
+	registerService := application.NewRegisterService(
+		logger,
+		store.userAppender,
+		passwordFactory,
+		confirmationService,
+		registrationQueue,
+	)
+	sessionService := application.NewSessionService(
+		logger,
+		store.userFinder,
+		store.sessionAppender,
+		store.sessionFinder,
+		store.rotatedTokenFinder,
+		store.activeSessionFinder,
+		store.sessionRotator,
+		store.sessionRevoker,
+		events.NewLogPublisher(logger),
+		application.SessionConfig{
+			MaxTokenRetries:     cfg.Sessions.MaxTokenRetries,
+			SessionDuration:     cfg.Sessions.Duration,
+			AccessTokenDuration: cfg.Sessions.AccessTokenDuration,
+			SigningKeys:         signingKeys,
+			Issuer:              cfg.Sessions.Issuer,
+			Audience:            cfg.Sessions.Audience,
+			ClientId:            cfg.Sessions.ClientId,
+			Scopes:              cfg.Sessions.Scopes,
+			Claims:              application.ProfileClaims,
+			RestrictUnconfirmed: confirmationPolicy == application.EmailConfirmationRestricted,
+			RefreshTokenPepper:  []byte(cfg.Sessions.RefreshTokenPepper),
+			Policy: application.SessionPolicy{
+				Mode:        application.SessionPolicyMode(cfg.Sessions.Policy),
+				MaxSessions: cfg.Sessions.MaxPerUser,
+			},
+		},
+	)
+	loginService := application.NewLoginService(
+		logger,
+		store.userFinder,
+		store.userUpdater,
+		passwordHasher,
+		sessionService,
+		confirmationPolicy,
+	)
+	resetService := application.NewPasswordResetService(
+		logger,
+		store.userFinder,
+		store.userUpdater,
+		store.actionTokenAppender,
+		store.actionTokenFinder,
+		store.actionTokenConsumer,
+		store.actionCooldownClaimer,
+		passwordFactory,
+		sessionService,
+		mailer,
+		mailTemplates,
+		resetQueue,
+		application.PasswordResetConfig{
+			TokenDuration:   cfg.PasswordReset.TokenDuration,
+			RequestCooldown: cfg.PasswordReset.RequestCooldown,
+			LinkTemplate:    cfg.PasswordReset.LinkTemplate,
+			TokenPepper:     []byte(cfg.Sessions.RefreshTokenPepper),
+		},
+	)
+	passwordChangeService := application.NewPasswordChangeService(
+		logger,
+		store.userFinder,
+		store.userUpdater,
+		passwordHasher,
+		passwordFactory,
+		sessionService,
+	)
+	sessionReaper := application.NewSessionReaper(
+		logger,
+		store.sessionRemover,
+		cfg.Sessions.ReaperInterval,
+		cfg.Sessions.ReaperBatchSize,
+	)
+
+	backgroundCtx, stopBackground := context.WithCancel(ctx)
+	// The task queues outlive ctx: they are only stopped once the server has
+	// shut down, so that work queued by the last requests is still done.
+	queueCtx, stopQueue := context.WithCancel(context.WithoutCancel(ctx))
+	var background sync.WaitGroup
+	background.Add(3 + len(taskQueues))
+	for _, queue := range taskQueues {
+		go func() {
+			defer background.Done()
+			queue.Run(queueCtx)
+		}()
+	}
+	go func() {
+		defer background.Done()
+		sessionReaper.Run(backgroundCtx)
+	}()
+	go func() {
+		defer background.Done()
+		keyRotator.Run(backgroundCtx)
+	}()
+	go func() {
+		defer background.Done()
+		reloadKeysOnHangup(backgroundCtx, logger, keyRotator)
+	}()
+	defer func() {
+		stopBackground()
+		stopQueue()
+		background.Wait()
+	}()
+
+	server := &http.Server{
+		Addr: cfg.HTTP.Address,
+		Handler: rest.NewHandler(
+			logger,
+			registerService,
+			loginService,
+			sessionService,
+			confirmationService,
+			resetService,
+			passwordChangeService,
+			catalog,
+			cfg.Introspection.Clients,
+		),
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("Starting HTTP server", slog.String("address", cfg.HTTP.Address))
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("http server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	logger.Info("Shutting down HTTP server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to shut down http server: %w", err)
+	}
+
+	return nil
+}
+
+// reloadKeysOnHangup reloads the signing keys whenever the process receives
+// SIGHUP, so a key added with keyctl is picked up without waiting for the
+// next scheduled reload.
+func reloadKeysOnHangup(ctx context.Context, logger *slog.Logger, rotator *keys.Rotator) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+		}
+
+		logger.InfoContext(ctx, "Reloading signing keys")
+		if err := rotator.Reload(); err != nil {
+			logger.ErrorContext(ctx, "Failed to reload signing keys", slog.Any("error", err))
+		}
+	}
 }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/maxdikun/users-api/internal/application"
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/breached"
+	"github.com/maxdikun/users-api/internal/config"
+	"github.com/maxdikun/users-api/internal/entities"
+	"github.com/maxdikun/users-api/internal/passwords"
+	"github.com/maxdikun/users-api/internal/strength"
+)
+
+func newPasswordHasher(cfg config.Passwords) (*passwords.Hasher, error) {
+	argon2id := passwords.DefaultArgon2id
+	argon2id.Memory = uint32(cfg.Argon2Memory)
+	argon2id.Iterations = uint32(cfg.Argon2Iterations)
+	argon2id.Parallelism = uint8(cfg.Argon2Parallelism)
+
+	return passwords.New(cfg.HashAlgorithm, argon2id, passwords.Bcrypt{Cost: cfg.BcryptCost})
+}
+
+// newPasswordFactory opens the breach corpus, if one is configured, and
+// combines it with the password policy and hasher.
+func newPasswordFactory(
+	logger *slog.Logger,
+	cfg config.Passwords,
+	hasher *passwords.Hasher,
+) (*application.PasswordFactory, error) {
+	var checker ports.BreachedPasswordChecker
+	if cfg.BreachCorpus != "" {
+		var err error
+		checker, err = breached.Open(cfg.BreachCorpus)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	policy := entities.PasswordPolicy{
+		MinLength:        cfg.MinLength,
+		MaxLength:        cfg.MaxLength,
+		MinClasses:       cfg.MinClasses,
+		BannedSubstrings: cfg.BannedSubstrings,
+		BanUserInputs:    cfg.BanUserInputs,
+		MinStrength:      cfg.MinStrength,
+		Estimator:        strength.Estimator{},
+	}
+	for _, name := range cfg.RequiredClasses {
+		class, err := entities.ParseCharacterClass(name)
+		if err != nil {
+			return nil, err
+		}
+		policy.RequiredClasses = append(policy.RequiredClasses, class)
+	}
+	// Reject overlong passwords with the policy's message rather than
+	// bcrypt's.
+	if cfg.HashAlgorithm == passwords.AlgorithmBcrypt {
+		policy.MaxLength = min(policy.MaxLength, passwords.MaxBcryptLength)
+	}
+
+	return application.NewPasswordFactory(logger, hasher, application.PasswordConfig{
+		Policy:         policy,
+		BreachChecker:  checker,
+		BreachPolicy:   application.BreachPolicy(cfg.BreachPolicy),
+		BreachMinCount: cfg.BreachMinCount,
+	}), nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maxdikun/users-api/internal/application"
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/config"
+	"github.com/maxdikun/users-api/internal/storage/memory"
+	"github.com/maxdikun/users-api/internal/storage/postgres"
+)
+
+type storage struct {
+	userAppender          ports.UserAppender
+	userFinder            ports.UserFinder
+	userUpdater           ports.UserUpdater
+	sessionAppender       ports.SessionAppender
+	sessionFinder         ports.SessionFinder
+	rotatedTokenFinder    ports.RotatedTokenFinder
+	activeSessionFinder   ports.ActiveSessionFinder
+	sessionRotator        ports.SessionRotator
+	sessionRevoker        ports.SessionRevoker
+	sessionRemover        ports.ExpiredSessionRemover
+	actionTokenAppender   ports.ActionTokenAppender
+	actionTokenFinder     ports.ActionTokenFinder
+	actionTokenConsumer   ports.ActionTokenConsumer
+	actionCooldownClaimer ports.ActionCooldownClaimer
+
+	close func()
+}
+
+func newStorage(ctx context.Context, logger *slog.Logger, cfg config.Config) (storage, error) {
+	switch cfg.Storage.Driver {
+	case config.StorageDriverMemory:
+		return newMemoryStorage(), nil
+	default:
+		return newPostgresStorage(ctx, logger, cfg.Postgres, []byte(cfg.Sessions.RefreshTokenPepper))
+	}
+}
+
+func newPostgresStorage(ctx context.Context, logger *slog.Logger, cfg config.Postgres, pepper []byte) (storage, error) {
+	pool, err := pgxpool.New(ctx, cfg.URL)
+	if err != nil {
+		return storage{}, fmt.Errorf("failed to create postgres pool: %w", err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return storage{}, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	hashed, err := postgres.NewTokenMigrator(pool).HashPlaintextTokens(ctx, func(token string) string {
+		return application.HashRefreshToken(pepper, token)
+	})
+	if err != nil {
+		pool.Close()
+		return storage{}, fmt.Errorf("failed to hash plaintext refresh tokens: %w", err)
+	}
+	if hashed > 0 {
+		logger.Info("Hashed plaintext refresh tokens", slog.Int("count", hashed))
+	}
+
+	sessionFinder := postgres.NewSessionFinder(pool)
+	actionTokenFinder := postgres.NewActionTokenFinder(pool)
+
+	return storage{
+		userAppender:          postgres.NewUserAppender(pool),
+		userFinder:            postgres.NewUserFinder(pool),
+		userUpdater:           postgres.NewUserUpdater(pool),
+		sessionAppender:       postgres.NewSessionAppender(pool),
+		sessionFinder:         sessionFinder,
+		rotatedTokenFinder:    sessionFinder,
+		activeSessionFinder:   sessionFinder,
+		sessionRotator:        postgres.NewSessionRotator(pool),
+		sessionRevoker:        postgres.NewSessionRevoker(pool),
+		sessionRemover:        postgres.NewExpiredSessionRemover(pool),
+		actionTokenAppender:   postgres.NewActionTokenAppender(pool),
+		actionTokenFinder:     actionTokenFinder,
+		actionTokenConsumer:   actionTokenFinder,
+		actionCooldownClaimer: postgres.NewActionCooldownClaimer(pool),
+		close:                 pool.Close,
+	}, nil
+}
+
+func newMemoryStorage() storage {
+	db := memory.NewDatabase()
+	sessionFinder := memory.NewSessionFinder(db)
+	actionTokenFinder := memory.NewActionTokenFinder(db)
+
+	return storage{
+		userAppender:          memory.NewUserAppender(db),
+		userFinder:            memory.NewUserFinder(db),
+		userUpdater:           memory.NewUserUpdater(db),
+		sessionAppender:       memory.NewSessionAppender(db),
+		sessionFinder:         sessionFinder,
+		rotatedTokenFinder:    sessionFinder,
+		activeSessionFinder:   sessionFinder,
+		sessionRotator:        memory.NewSessionRotator(db),
+		sessionRevoker:        memory.NewSessionRevoker(db),
+		sessionRemover:        memory.NewExpiredSessionRemover(db),
+		actionTokenAppender:   memory.NewActionTokenAppender(db),
+		actionTokenFinder:     actionTokenFinder,
+		actionTokenConsumer:   actionTokenFinder,
+		actionCooldownClaimer: memory.NewActionCooldownClaimer(db),
+		close:                 func() {},
+	}
+}
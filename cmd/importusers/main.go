@@ -0,0 +1,175 @@
+// Command importusers loads users migrated from another system into the
+// database. It reads one JSON object per line,
+//
+//	{"username": "alice", "email": "alice@example.com", "password_hash": "$pbkdf2-sha256$...",
+//	 "created_at": "2019-05-01T10:00:00Z", "email_confirmed_at": "2019-05-01T10:05:00Z"}
+//
+// where created_at and email_confirmed_at are optional. Password hashes are
+// stored as they are and must be in a format the API can verify, with work
+// factors it accepts; each one is replaced by the configured algorithm on its
+// user's first login.
+//
+// Users whose username or email is taken are skipped, as are lines that fail
+// validation; both are reported on standard error.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+	"github.com/maxdikun/users-api/internal/passwords"
+	"github.com/maxdikun/users-api/internal/storage/postgres"
+)
+
+type record struct {
+	Username         string     `json:"username"`
+	Email            string     `json:"email"`
+	PasswordHash     string     `json:"password_hash"`
+	CreatedAt        *time.Time `json:"created_at"`
+	EmailConfirmedAt *time.Time `json:"email_confirmed_at"`
+}
+
+type summary struct {
+	imported, skipped, invalid int
+}
+
+func main() {
+	file := flag.String("file", "-", "JSON lines to import, - for standard input")
+	postgresURL := flag.String("postgres-url", os.Getenv("POSTGRES_URL"), "Postgres connection string")
+	dryRun := flag.Bool("dry-run", false, "validate the input without writing anything")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, *file, *postgresURL, *dryRun); err != nil {
+		fmt.Fprintf(os.Stderr, "importusers: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, file string, postgresURL string, dryRun bool) error {
+	var input io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	var appender ports.UserAppender
+	if !dryRun {
+		if postgresURL == "" {
+			return errors.New("-postgres-url or POSTGRES_URL is required")
+		}
+
+		pool, err := pgxpool.New(ctx, postgresURL)
+		if err != nil {
+			return fmt.Errorf("failed to create postgres pool: %w", err)
+		}
+		defer pool.Close()
+
+		if err := pool.Ping(ctx); err != nil {
+			return fmt.Errorf("failed to connect to postgres: %w", err)
+		}
+		appender = postgres.NewUserAppender(pool)
+	}
+
+	// Only used to recognise hash formats, so its parameters do not matter.
+	hasher, err := passwords.New(passwords.AlgorithmArgon2id, passwords.DefaultArgon2id, passwords.DefaultBcrypt)
+	if err != nil {
+		return err
+	}
+
+	var sum summary
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		user, err := parse(scanner.Bytes(), hasher)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "line %d: %v\n", line, err)
+			sum.invalid++
+			continue
+		}
+
+		if dryRun {
+			sum.imported++
+			continue
+		}
+
+		err = appender.AppendUser(ctx, user)
+		var duplication *ports.DuplicationError
+		switch {
+		case errors.As(err, &duplication):
+			fmt.Fprintf(os.Stderr, "line %d: %s is taken, skipped\n", line, duplication.Field)
+			sum.skipped++
+		case err != nil:
+			return fmt.Errorf("line %d: %w", line, err)
+		default:
+			sum.imported++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	fmt.Printf("imported %d, skipped %d, invalid %d\n", sum.imported, sum.skipped, sum.invalid)
+	if sum.invalid > 0 {
+		return fmt.Errorf("%d invalid lines", sum.invalid)
+	}
+	return nil
+}
+
+func parse(data []byte, hasher *passwords.Hasher) (entities.User, error) {
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return entities.User{}, err
+	}
+
+	username, usernameErr := entities.NewUsername(rec.Username)
+	email, emailErr := entities.NewEmail(rec.Email)
+	if err := errors.Join(usernameErr, emailErr); err != nil {
+		return entities.User{}, err
+	}
+
+	password := entities.RawPassword(rec.PasswordHash)
+	if !hasher.Supported(password) {
+		return entities.User{}, passwords.ErrUnsupportedHash
+	}
+
+	now := time.Now()
+	createdAt := now
+	if rec.CreatedAt != nil {
+		createdAt = *rec.CreatedAt
+	}
+
+	return entities.LoadUser(
+		uuid.New(),
+		username,
+		email,
+		password,
+		createdAt,
+		rec.EmailConfirmedAt,
+		now,
+		false,
+	), nil
+}
@@ -0,0 +1,127 @@
+// Command keyctl manages a directory of access token signing keys, see
+// ACCESS_TOKEN_SIGNING_KEY_DIR. After changing the directory, send SIGHUP to
+// the API or wait for its next reload.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/maxdikun/users-api/internal/keys"
+)
+
+const usage = `usage: keyctl <command> [flags]
+
+commands:
+  generate  add a new key to the directory, active after a delay
+  list      show the keys in the directory
+  prune     delete retired keys that were superseded long ago
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "generate":
+		err = generate(os.Args[2:])
+	case "list":
+		err = list(os.Args[2:])
+	case "prune":
+		err = prune(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keyctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func generate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	dir := flags.String("dir", "", "key directory")
+	algorithm := flags.String("alg", keys.AlgorithmEdDSA, "RS256, ES256 or EdDSA")
+	delay := flags.Duration("delay", 10*time.Minute, "how long the key is only published, at least ACCESS_TOKEN_KEY_PUBLISH_DELAY")
+	_ = flags.Parse(args)
+
+	if *dir == "" {
+		return fmt.Errorf("-dir is required")
+	}
+
+	key, err := keys.Generate(*algorithm)
+	if err != nil {
+		return err
+	}
+
+	// Nothing can have cached keys of an empty directory, so its first key
+	// is active right away.
+	now := time.Now()
+	entries, err := keys.Dir(*dir).Entries(now)
+	if err != nil {
+		return err
+	}
+	activeFrom := now.Add(*delay)
+	if len(entries) == 0 {
+		activeFrom = now
+	}
+
+	path, err := keys.Dir(*dir).Add(key, activeFrom)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\t%s\t%s\n", path, key.Id(), key.Algorithm())
+	return nil
+}
+
+func list(args []string) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	dir := flags.String("dir", "", "key directory")
+	_ = flags.Parse(args)
+
+	if *dir == "" {
+		return fmt.Errorf("-dir is required")
+	}
+
+	entries, err := keys.Dir(*dir).Entries(time.Now())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tKID\tALG\tCREATED\tACTIVE FROM\tPATH")
+	for _, entry := range entries {
+		fmt.Fprintf(
+			w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			entry.Status, entry.Key.Id(), entry.Key.Algorithm(),
+			entry.ModTime.UTC().Format(time.RFC3339), entry.ActiveFrom.UTC().Format(time.RFC3339), entry.Path,
+		)
+	}
+	return w.Flush()
+}
+
+func prune(args []string) error {
+	flags := flag.NewFlagSet("prune", flag.ExitOnError)
+	dir := flags.String("dir", "", "key directory")
+	retention := flags.Duration("retention", time.Hour, "how long a superseded key stays published, at least ACCESS_TOKEN_DURATION")
+	_ = flags.Parse(args)
+
+	if *dir == "" {
+		return fmt.Errorf("-dir is required")
+	}
+
+	removed, err := keys.Dir(*dir).Prune(*retention, time.Now())
+	for _, path := range removed {
+		fmt.Println(path)
+	}
+	return err
+}
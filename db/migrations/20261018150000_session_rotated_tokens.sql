@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sessions ADD COLUMN revoked_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS session_rotated_tokens(
+    token VARCHAR(255) PRIMARY KEY,
+    session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    rotated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS session_rotated_tokens_session_id_idx ON session_rotated_tokens(session_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE session_rotated_tokens;
+
+ALTER TABLE sessions DROP COLUMN revoked_at;
+-- +goose StatementEnd
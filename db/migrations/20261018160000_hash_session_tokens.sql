@@ -0,0 +1,26 @@
+-- Refresh tokens are stored as hex-encoded HMAC-SHA256 digests keyed with the
+-- server pepper. Only the API knows the pepper, so this migration merely
+-- renames the columns: rows from before it still hold plaintext tokens, which
+-- the API hashes in place when it starts (see postgres.TokenMigrator), so that
+-- sessions issued before this migration keep working.
+--
+-- The down migration cannot recover plaintext tokens, so it drops every
+-- session instead.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sessions RENAME COLUMN token TO token_hash;
+ALTER TABLE sessions RENAME CONSTRAINT sessions_token_key TO sessions_token_hash_key;
+
+ALTER TABLE session_rotated_tokens RENAME COLUMN token TO token_hash;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM sessions;
+
+ALTER TABLE session_rotated_tokens RENAME COLUMN token_hash TO token;
+
+ALTER TABLE sessions RENAME CONSTRAINT sessions_token_hash_key TO sessions_token_key;
+ALTER TABLE sessions RENAME COLUMN token_hash TO token;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- One row per user and purpose, claimed with a conditional upsert so that
+-- concurrent requests cannot both pass the cooldown of a mail.
+CREATE TABLE IF NOT EXISTS action_cooldowns(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(32) NOT NULL,
+    claimed_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, purpose)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE action_cooldowns;
+-- +goose StatementEnd
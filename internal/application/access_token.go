@@ -0,0 +1,136 @@
+package application
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// AccessToken is the verified content of an access token issued by
+// SessionService. Its claims follow the JWT access token profile of RFC 9068.
+type AccessToken struct {
+	Id        string
+	Subject   uuid.UUID
+	SessionId uuid.UUID
+	Issuer    string
+	Audience  []string
+	ClientId  string
+	Scopes    []string
+	IssuedAt  time.Time
+	NotBefore time.Time
+	ExpiresAt time.Time
+	// Claims holds the claims added by the service's ClaimsFunc.
+	Claims map[string]any
+	// KeyId is the id of the key that signed the token.
+	KeyId string
+}
+
+// ClaimsFunc derives additional access token claims from the user the token
+// is issued to. Claims that clash with the registered ones are ignored.
+type ClaimsFunc func(user entities.User) map[string]any
+
+// ProfileClaims exposes the username and whether the email address has been
+// confirmed, so downstream services need not look the user up for basics.
+func ProfileClaims(user entities.User) map[string]any {
+	return map[string]any{
+		"preferred_username": string(user.Username()),
+		"email_verified":     user.EmailConfirmedAt() != nil,
+	}
+}
+
+// accessTokenType is the "typ" header RFC 9068 requires, which keeps access
+// tokens from being confused with other JWTs signed by the same keys.
+const accessTokenType = "at+jwt"
+
+var reservedClaims = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti", "sid", "scope", "client_id"}
+
+func (svc *SessionService) accessTokenClaims(user entities.User, session entities.Session) jwt.MapClaims {
+	claims := jwt.MapClaims{}
+	if svc.claims != nil {
+		for name, value := range svc.claims(user) {
+			claims[name] = value
+		}
+	}
+	for _, name := range reservedClaims {
+		delete(claims, name)
+	}
+
+	now := time.Now()
+	claims["iss"] = svc.issuer
+	claims["sub"] = user.Id().String()
+	claims["aud"] = svc.audience
+	claims["client_id"] = svc.clientId
+	claims["iat"] = jwt.NewNumericDate(now)
+	claims["nbf"] = jwt.NewNumericDate(now)
+	claims["exp"] = jwt.NewNumericDate(now.Add(svc.accessTokenDuration))
+	claims["jti"] = uuid.NewString()
+	claims["sid"] = session.Id().String()
+	restricted := svc.restrictUnconfirmed && user.EmailConfirmedAt() == nil
+	if len(svc.scopes) > 0 && !restricted {
+		claims["scope"] = strings.Join(svc.scopes, " ")
+	}
+
+	return claims
+}
+
+func parseAccessToken(token *jwt.Token) (AccessToken, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return AccessToken{}, errors.New("unexpected claims type")
+	}
+
+	subject, err := uuid.Parse(stringClaim(claims, "sub"))
+	if err != nil {
+		return AccessToken{}, err
+	}
+	session, err := uuid.Parse(stringClaim(claims, "sid"))
+	if err != nil {
+		return AccessToken{}, err
+	}
+
+	audience, err := claims.GetAudience()
+	if err != nil {
+		return AccessToken{}, err
+	}
+
+	accessToken := AccessToken{
+		Id:        stringClaim(claims, "jti"),
+		Subject:   subject,
+		SessionId: session,
+		Issuer:    stringClaim(claims, "iss"),
+		Audience:  audience,
+		ClientId:  stringClaim(claims, "client_id"),
+		Scopes:    strings.Fields(stringClaim(claims, "scope")),
+		IssuedAt:  timeClaim(claims.GetIssuedAt()),
+		NotBefore: timeClaim(claims.GetNotBefore()),
+		ExpiresAt: timeClaim(claims.GetExpirationTime()),
+		Claims:    make(map[string]any),
+	}
+	accessToken.KeyId, _ = token.Header["kid"].(string)
+
+	for name, value := range claims {
+		if !slices.Contains(reservedClaims, name) {
+			accessToken.Claims[name] = value
+		}
+	}
+
+	return accessToken, nil
+}
+
+func stringClaim(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+func timeClaim(date *jwt.NumericDate, err error) time.Time {
+	if err != nil || date == nil {
+		return time.Time{}
+	}
+	return date.Time
+}
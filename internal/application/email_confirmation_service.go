@@ -0,0 +1,234 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+var ErrInvalidActionToken = errors.New("token is invalid, expired or already used")
+
+// EmailConfirmationPolicy decides what users may do before confirming their
+// email address.
+type EmailConfirmationPolicy string
+
+const (
+	// EmailConfirmationOptional lets unconfirmed users do everything.
+	EmailConfirmationOptional EmailConfirmationPolicy = "optional"
+	// EmailConfirmationRestricted lets unconfirmed users log in, but their
+	// access tokens carry no scopes.
+	EmailConfirmationRestricted EmailConfirmationPolicy = "restricted"
+	// EmailConfirmationRequired refuses to log unconfirmed users in.
+	EmailConfirmationRequired EmailConfirmationPolicy = "required"
+)
+
+type EmailConfirmationConfig struct {
+	TokenDuration time.Duration
+	// ResendCooldown is how long a user has to wait before another
+	// confirmation mail is sent.
+	ResendCooldown time.Duration
+	// LinkTemplate is the URL mailed to users, with "{token}" replaced by the
+	// confirmation token.
+	LinkTemplate string
+	// TokenPepper is the secret the hash key of confirmation tokens is derived
+	// from. It may be shared with other kinds of token.
+	TokenPepper []byte
+}
+
+// confirmationMail is the data of the email_confirmation template.
+type confirmationMail struct {
+	Username  string
+	Link      string
+	ExpiresIn time.Duration
+}
+
+type EmailConfirmationService struct {
+	logger *slog.Logger
+
+	userFinder    ports.UserFinder
+	userUpdater   ports.UserUpdater
+	tokenAppender ports.ActionTokenAppender
+	tokenConsumer ports.ActionTokenConsumer
+	cooldowns     ports.ActionCooldownClaimer
+	mailSender    mailSender
+	tasks         *TaskQueue
+
+	tokenDuration  time.Duration
+	resendCooldown time.Duration
+	linkTemplate   string
+	tokenHasher    tokenHasher
+}
+
+func NewEmailConfirmationService(
+	logger *slog.Logger,
+	userFinder ports.UserFinder,
+	userUpdater ports.UserUpdater,
+	tokenAppender ports.ActionTokenAppender,
+	tokenConsumer ports.ActionTokenConsumer,
+	cooldowns ports.ActionCooldownClaimer,
+	mailer ports.Mailer,
+	renderer ports.MailRenderer,
+	tasks *TaskQueue,
+	config EmailConfirmationConfig,
+) *EmailConfirmationService {
+	return &EmailConfirmationService{
+		logger:         logger,
+		userFinder:     userFinder,
+		userUpdater:    userUpdater,
+		tokenAppender:  tokenAppender,
+		tokenConsumer:  tokenConsumer,
+		cooldowns:      cooldowns,
+		mailSender:     mailSender{mailer: mailer, renderer: renderer},
+		tasks:          tasks,
+		tokenDuration:  config.TokenDuration,
+		resendCooldown: config.ResendCooldown,
+		linkTemplate:   config.LinkTemplate,
+		tokenHasher:    newActionTokenHasher(config.TokenPepper, entities.ActionEmailConfirmation),
+	}
+}
+
+// SendConfirmation issues a confirmation token for the user's email address
+// and mails it to them in locale, or the default locale when empty. It does
+// nothing while the previous mail is younger than the resend cooldown.
+func (svc *EmailConfirmationService) SendConfirmation(ctx context.Context, user entities.User, locale string) error {
+	claimed, err := svc.cooldowns.ClaimActionCooldown(
+		ctx, user.Id(), entities.ActionEmailConfirmation, time.Now(), svc.resendCooldown,
+	)
+	if err != nil {
+		svc.logger.ErrorContext(ctx, "Failed to claim confirmation cooldown", slog.Any("error", err))
+		return ErrInternal
+	}
+	if !claimed {
+		svc.logger.InfoContext(
+			ctx, "Confirmation mail skipped: cooldown",
+			slog.String("user_id", user.Id().String()),
+		)
+		return nil
+	}
+
+	token, err := generateRandomString(32)
+	if err != nil {
+		svc.logger.ErrorContext(ctx, "Generating confirmation token failed", slog.Any("error", err))
+		return ErrInternal
+	}
+
+	actionToken := entities.NewActionToken(
+		user.Id(), entities.ActionEmailConfirmation, svc.tokenHasher.hash(token), svc.tokenDuration,
+	)
+	if err := svc.tokenAppender.AppendActionToken(ctx, actionToken); err != nil {
+		svc.logger.ErrorContext(
+			ctx, "Failed to store confirmation token",
+			slog.String("user_id", user.Id().String()),
+			slog.Any("error", err),
+		)
+		return ErrInternal
+	}
+
+	link := strings.ReplaceAll(svc.linkTemplate, "{token}", token)
+	err = svc.mailSender.send(ctx, user.Email(), mailEmailConfirmation, locale, confirmationMail{
+		Username:  string(user.Username()),
+		Link:      link,
+		ExpiresIn: svc.tokenDuration,
+	})
+	if err != nil {
+		svc.logger.ErrorContext(
+			ctx, "Failed to send confirmation mail",
+			slog.String("user_id", user.Id().String()),
+			slog.Any("error", err),
+		)
+		return ErrInternal
+	}
+
+	svc.logger.InfoContext(ctx, "Confirmation mail sent", slog.String("user_id", user.Id().String()))
+	return nil
+}
+
+// ResendConfirmation queues a new confirmation mail in locale to the owner
+// of the address. To not reveal which addresses are registered, it returns
+// before looking the address up, and the queued work quietly does nothing for
+// unknown, deleted or already confirmed users and while the previous mail is
+// younger than the resend cooldown. Failures are only logged.
+func (svc *EmailConfirmationService) ResendConfirmation(ctx context.Context, email string, locale string) {
+	emailObj, err := entities.NewEmail(email)
+	if err != nil {
+		return
+	}
+
+	svc.tasks.EnqueueFor(ctx, "resend_confirmation", strings.ToLower(string(emailObj)), func(ctx context.Context) {
+		svc.resendConfirmation(ctx, emailObj, locale)
+	})
+}
+
+func (svc *EmailConfirmationService) resendConfirmation(ctx context.Context, email entities.Email, locale string) {
+	user, err := svc.userFinder.FindByEmail(ctx, email)
+	if err != nil {
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			svc.logger.InfoContext(ctx, "Confirmation resend skipped: unknown email")
+			return
+		}
+
+		svc.logger.ErrorContext(ctx, "Failed to find user", slog.Any("error", err))
+		return
+	}
+
+	if user.IsDeleted() || user.EmailConfirmedAt() != nil {
+		svc.logger.InfoContext(
+			ctx, "Confirmation resend skipped: user deleted or already confirmed",
+			slog.String("user_id", user.Id().String()),
+		)
+		return
+	}
+
+	// SendConfirmation logs its own failures.
+	_ = svc.SendConfirmation(ctx, user, locale)
+}
+
+// ConfirmEmail consumes a confirmation token and marks the email address of
+// its user as confirmed.
+func (svc *EmailConfirmationService) ConfirmEmail(ctx context.Context, token string) error {
+	actionToken, err := svc.tokenConsumer.ConsumeActionToken(
+		ctx, entities.ActionEmailConfirmation, svc.tokenHasher.hash(token), time.Now(),
+	)
+	if err != nil {
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			return ErrInvalidActionToken
+		}
+
+		svc.logger.ErrorContext(ctx, "Failed to consume confirmation token", slog.Any("error", err))
+		return ErrInternal
+	}
+
+	user, err := svc.userFinder.FindById(ctx, actionToken.User())
+	if err != nil {
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			return ErrInvalidActionToken
+		}
+
+		svc.logger.ErrorContext(ctx, "Failed to find user", slog.Any("error", err))
+		return ErrInternal
+	}
+
+	if user.IsDeleted() {
+		return ErrInvalidActionToken
+	}
+
+	if err := svc.userUpdater.ConfirmEmail(ctx, user.Id(), time.Now()); err != nil {
+		svc.logger.ErrorContext(
+			ctx, "Failed to store email confirmation",
+			slog.String("user_id", user.Id().String()),
+			slog.Any("error", err),
+		)
+		return ErrInternal
+	}
+
+	svc.logger.InfoContext(ctx, "Email address confirmed", slog.String("user_id", user.Id().String()))
+	return nil
+}
@@ -0,0 +1,203 @@
+package application
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/maxdikun/users-api/internal/entities"
+	"github.com/maxdikun/users-api/internal/keys"
+	"github.com/maxdikun/users-api/internal/passwords"
+	"github.com/maxdikun/users-api/internal/storage/memory"
+)
+
+// testArgon2id keeps the tests quick. Hashes of weakArgon2id are outdated
+// against it.
+var (
+	testArgon2id = passwords.Argon2id{Memory: 64, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	weakArgon2id = passwords.Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+)
+
+var testDevice = entities.Device{Name: "laptop", UserAgent: "test", IP: "127.0.0.1"}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func newTestHasher(t *testing.T, algorithm string, argon2id passwords.Argon2id) *passwords.Hasher {
+	t.Helper()
+
+	h, err := passwords.New(algorithm, argon2id, passwords.Bcrypt{Cost: bcrypt.MinCost})
+	if err != nil {
+		t.Fatalf("passwords.New() error = %v", err)
+	}
+	return h
+}
+
+// countingHasher records the hashes it verifies passwords against.
+type countingHasher struct {
+	entities.PasswordHasher
+
+	mu       sync.Mutex
+	verified []entities.Password
+}
+
+func (h *countingHasher) Verify(hash entities.Password, password string) (bool, bool, error) {
+	h.mu.Lock()
+	h.verified = append(h.verified, hash)
+	h.mu.Unlock()
+	return h.PasswordHasher.Verify(hash, password)
+}
+
+func (h *countingHasher) reset() []entities.Password {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	verified := h.verified
+	h.verified = nil
+	return verified
+}
+
+// recordingPublisher keeps the security events published.
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []entities.SecurityEvent
+}
+
+func (p *recordingPublisher) PublishSecurityEvent(_ context.Context, event entities.SecurityEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordingPublisher) published() []entities.SecurityEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]entities.SecurityEvent(nil), p.events...)
+}
+
+// fixture wires the services under test to one memory database.
+type fixture struct {
+	db       *memory.Database
+	hasher   *countingHasher
+	events   *recordingPublisher
+	sessions *SessionService
+}
+
+func newFixture(t *testing.T, policy SessionPolicy) *fixture {
+	t.Helper()
+
+	key, err := keys.Generate(keys.AlgorithmEdDSA)
+	if err != nil {
+		t.Fatalf("keys.Generate() error = %v", err)
+	}
+
+	db := memory.NewDatabase()
+	sessionFinder := memory.NewSessionFinder(db)
+	events := &recordingPublisher{}
+
+	return &fixture{
+		db:     db,
+		hasher: &countingHasher{PasswordHasher: newTestHasher(t, passwords.AlgorithmArgon2id, testArgon2id)},
+		events: events,
+		sessions: NewSessionService(
+			discardLogger(),
+			memory.NewUserFinder(db),
+			memory.NewSessionAppender(db),
+			sessionFinder,
+			sessionFinder,
+			sessionFinder,
+			memory.NewSessionRotator(db),
+			memory.NewSessionRevoker(db),
+			events,
+			SessionConfig{
+				MaxTokenRetries:     3,
+				SessionDuration:     time.Hour,
+				AccessTokenDuration: time.Minute,
+				SigningKeys:         keys.NewRing(time.Hour, keys.Bundle{Active: key, Since: time.Now()}),
+				Issuer:              "https://users.example.com",
+				Audience:            []string{"users-api"},
+				ClientId:            "users-api",
+				RefreshTokenPepper:  []byte("test pepper"),
+				Policy:              policy,
+			},
+		),
+	}
+}
+
+// addUser stores a confirmed user whose password is hashed by the
+// fixture's hasher.
+func (f *fixture) addUser(t *testing.T, username string, password string) entities.User {
+	t.Helper()
+
+	hash, err := f.hasher.Hash(password)
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	user := loadUser(username, hash, true, false)
+	f.storeUser(t, user)
+	return user
+}
+
+func (f *fixture) storeUser(t *testing.T, user entities.User) {
+	t.Helper()
+
+	if err := memory.NewUserAppender(f.db).AppendUser(context.Background(), user); err != nil {
+		t.Fatalf("AppendUser() error = %v", err)
+	}
+}
+
+// loadUser builds a user with the email address username@example.com.
+func loadUser(username string, hash entities.Password, confirmed bool, deleted bool) entities.User {
+	now := time.Now()
+	var confirmedAt *time.Time
+	if confirmed {
+		confirmedAt = &now
+	}
+	return entities.LoadUser(
+		uuid.New(),
+		entities.Username(username),
+		entities.Email(username+"@example.com"),
+		hash,
+		now,
+		confirmedAt,
+		now,
+		deleted,
+	)
+}
+
+func (f *fixture) storedPassword(t *testing.T, user entities.User) entities.Password {
+	t.Helper()
+
+	stored, err := memory.NewUserFinder(f.db).FindById(context.Background(), user.Id())
+	if err != nil {
+		t.Fatalf("FindById() error = %v", err)
+	}
+	return stored.Password()
+}
+
+func (f *fixture) activeSessions(t *testing.T, user entities.User) []entities.Session {
+	t.Helper()
+
+	sessions, err := f.sessions.ListSessions(context.Background(), user.Id())
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	return sessions
+}
+
+func (f *fixture) login(t *testing.T, user entities.User) TokenSet {
+	t.Helper()
+
+	tokens, err := f.sessions.CreateSession(context.Background(), user, testDevice)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	return tokens
+}
@@ -0,0 +1,160 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid login or password")
+	ErrEmailNotConfirmed  = errors.New("email address is not confirmed")
+)
+
+type LoginService struct {
+	logger *slog.Logger
+
+	userFinder     ports.UserFinder
+	userUpdater    ports.UserUpdater
+	hasher         entities.PasswordHasher
+	sessionService *SessionService
+
+	confirmationPolicy EmailConfirmationPolicy
+
+	// dummyHash is checked against when there is no user to check the
+	// password of, so that such logins take as long as a wrong password.
+	dummyHash entities.Password
+}
+
+func NewLoginService(
+	logger *slog.Logger,
+	userFinder ports.UserFinder,
+	userUpdater ports.UserUpdater,
+	hasher entities.PasswordHasher,
+	sessionService *SessionService,
+	confirmationPolicy EmailConfirmationPolicy,
+) *LoginService {
+	dummyHash, err := hasher.Hash("dummy password")
+	if err != nil {
+		logger.Error("Failed to hash dummy password, logins of unknown users will be answered faster", slog.Any("error", err))
+	}
+
+	return &LoginService{
+		logger:             logger,
+		userFinder:         userFinder,
+		userUpdater:        userUpdater,
+		hasher:             hasher,
+		sessionService:     sessionService,
+		confirmationPolicy: confirmationPolicy,
+		dummyHash:          dummyHash,
+	}
+}
+
+// Login authenticates a user by username or email and opens a new session.
+// Unknown users, deleted users and wrong passwords all yield
+// ErrInvalidCredentials so the caller cannot tell them apart.
+func (svc *LoginService) Login(ctx context.Context, login string, password string, device entities.Device) (TokenSet, error) {
+	svc.logger.DebugContext(ctx, "LoginService.Login called")
+
+	user, err := svc.findUser(ctx, login)
+	if err != nil {
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			svc.logger.InfoContext(ctx, "Login failed: user not found")
+			svc.verifyDummy(password)
+			return TokenSet{}, ErrInvalidCredentials
+		}
+
+		svc.logger.ErrorContext(ctx, "Failed to find user", slog.Any("error", err))
+		return TokenSet{}, ErrInternal
+	}
+
+	if user.IsDeleted() {
+		svc.logger.InfoContext(ctx, "Login failed: user is deleted", slog.String("user_id", user.Id().String()))
+		svc.verifyDummy(password)
+		return TokenSet{}, ErrInvalidCredentials
+	}
+
+	ok, rehash, err := svc.hasher.Verify(user.Password(), password)
+	if err != nil {
+		svc.logger.ErrorContext(
+			ctx, "Failed to verify password",
+			slog.String("user_id", user.Id().String()),
+			slog.Any("error", err),
+		)
+		return TokenSet{}, ErrInternal
+	}
+	if !ok {
+		svc.logger.InfoContext(ctx, "Login failed: wrong password", slog.String("user_id", user.Id().String()))
+		return TokenSet{}, ErrInvalidCredentials
+	}
+	if rehash {
+		svc.rehashPassword(ctx, user, password)
+	}
+
+	// Checked only after the password, so the error does not reveal whether
+	// an address is registered.
+	if svc.confirmationPolicy == EmailConfirmationRequired && user.EmailConfirmedAt() == nil {
+		svc.logger.InfoContext(ctx, "Login refused: email not confirmed", slog.String("user_id", user.Id().String()))
+		return TokenSet{}, ErrEmailNotConfirmed
+	}
+
+	return svc.sessionService.CreateSession(ctx, user, device)
+}
+
+// verifyDummy spends the time of a password check on a login that fails
+// without one. The result does not matter.
+func (svc *LoginService) verifyDummy(password string) {
+	_, _, _ = svc.hasher.Verify(svc.dummyHash, password)
+}
+
+// rehashPassword replaces an outdated password hash while the plain text
+// password is at hand. Failing to do so is logged but does not fail the
+// login; the next one tries again.
+func (svc *LoginService) rehashPassword(ctx context.Context, user entities.User, password string) {
+	hash, err := svc.hasher.Hash(password)
+	if err != nil {
+		svc.logger.WarnContext(
+			ctx, "Failed to rehash password",
+			slog.String("user_id", user.Id().String()),
+			slog.Any("error", err),
+		)
+		return
+	}
+
+	if err := svc.userUpdater.UpdatePassword(ctx, user.Id(), user.Password(), hash); err != nil {
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			svc.logger.InfoContext(
+				ctx, "Skipped rehash of a password changed meanwhile",
+				slog.String("user_id", user.Id().String()),
+			)
+			return
+		}
+
+		svc.logger.WarnContext(
+			ctx, "Failed to store rehashed password",
+			slog.String("user_id", user.Id().String()),
+			slog.Any("error", err),
+		)
+		return
+	}
+
+	svc.logger.InfoContext(ctx, "Password rehashed", slog.String("user_id", user.Id().String()))
+}
+
+func (svc *LoginService) findUser(ctx context.Context, login string) (entities.User, error) {
+	if strings.Contains(login, "@") {
+		email, err := entities.NewEmail(login)
+		if err != nil {
+			return entities.User{}, &ports.NotFoundError{Source: "application.LoginService", Object: "user", Field: "email"}
+		}
+		return svc.userFinder.FindByEmail(ctx, email)
+	}
+
+	return svc.userFinder.FindByUsername(ctx, entities.Username(login))
+}
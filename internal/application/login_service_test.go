@@ -0,0 +1,190 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/maxdikun/users-api/internal/passwords"
+	"github.com/maxdikun/users-api/internal/storage/memory"
+)
+
+func newLoginService(f *fixture, confirmation EmailConfirmationPolicy) *LoginService {
+	return NewLoginService(
+		discardLogger(),
+		memory.NewUserFinder(f.db),
+		memory.NewUserUpdater(f.db),
+		f.hasher,
+		f.sessions,
+		confirmation,
+	)
+}
+
+func TestLoginServiceLogin(t *testing.T) {
+	const password = "correct horse"
+
+	tests := []struct {
+		name         string
+		confirmation EmailConfirmationPolicy
+		login        string
+		password     string
+		wantErr      error
+	}{
+		{"by username", EmailConfirmationOptional, "alice", password, nil},
+		{"by email", EmailConfirmationOptional, "alice@example.com", password, nil},
+		{"wrong password", EmailConfirmationOptional, "alice", "wrong horse", ErrInvalidCredentials},
+		{"unknown username", EmailConfirmationOptional, "nobody", password, ErrInvalidCredentials},
+		{"unknown email", EmailConfirmationOptional, "nobody@example.com", password, ErrInvalidCredentials},
+		{"malformed email", EmailConfirmationOptional, "@", password, ErrInvalidCredentials},
+		{"deleted user", EmailConfirmationOptional, "deleted", password, ErrInvalidCredentials},
+		{"unconfirmed without confirmation", EmailConfirmationOptional, "unconfirmed", password, nil},
+		{"unconfirmed with confirmation", EmailConfirmationRequired, "unconfirmed", password, ErrEmailNotConfirmed},
+		{"unconfirmed with wrong password", EmailConfirmationRequired, "unconfirmed", "wrong horse", ErrInvalidCredentials},
+		{"confirmed with confirmation", EmailConfirmationRequired, "alice", password, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, SessionPolicy{Mode: SessionPolicyUnlimited})
+			hash, err := f.hasher.Hash(password)
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			f.storeUser(t, loadUser("alice", hash, true, false))
+			f.storeUser(t, loadUser("deleted", hash, true, true))
+			f.storeUser(t, loadUser("unconfirmed", hash, false, false))
+
+			tokens, err := newLoginService(f, tt.confirmation).Login(context.Background(), tt.login, tt.password, testDevice)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Login() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if tokens.Access == "" || tokens.Refresh == "" {
+				t.Errorf("Login() = %+v, want access and refresh tokens", tokens)
+			}
+			if _, err := f.sessions.RefreshAccessToken(context.Background(), tokens.Refresh); err != nil {
+				t.Errorf("RefreshAccessToken() with the issued refresh token error = %v", err)
+			}
+		})
+	}
+}
+
+// Logins that fail before a password check must spend the time of one, so
+// their speed does not tell whether an account exists.
+func TestLoginServiceVerifiesDummyHash(t *testing.T) {
+	f := newFixture(t, SessionPolicy{Mode: SessionPolicyUnlimited})
+	f.addUser(t, "alice", "correct horse")
+	deleted, err := f.hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	f.storeUser(t, loadUser("deleted", deleted, true, true))
+	svc := newLoginService(f, EmailConfirmationOptional)
+	if ok, outdated, err := f.hasher.Verify(svc.dummyHash, "dummy password"); !ok || outdated || err != nil {
+		t.Fatalf("Verify() of dummy hash = %v, %v, %v, want a current hash", ok, outdated, err)
+	}
+
+	tests := []struct {
+		name      string
+		login     string
+		wantDummy bool
+	}{
+		{"unknown username", "nobody", true},
+		{"unknown email", "nobody@example.com", true},
+		{"malformed email", "@", true},
+		{"deleted user", "deleted", true},
+		{"wrong password", "alice", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.hasher.reset()
+			if _, err := svc.Login(context.Background(), tt.login, "wrong horse", testDevice); !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("Login() error = %v, want ErrInvalidCredentials", err)
+			}
+
+			verified := f.hasher.reset()
+			if len(verified) != 1 {
+				t.Fatalf("Login() verified %d hashes, want 1", len(verified))
+			}
+			if dummy := verified[0] == svc.dummyHash; dummy != tt.wantDummy {
+				t.Errorf("Login() verified the dummy hash = %v, want %v", dummy, tt.wantDummy)
+			}
+		})
+	}
+}
+
+func TestLoginServiceRehashesOutdatedPasswords(t *testing.T) {
+	const password = "correct horse"
+
+	tests := []struct {
+		name     string
+		hasher   *passwords.Hasher
+		rehashed bool
+	}{
+		{"current argon2id", newTestHasher(t, passwords.AlgorithmArgon2id, testArgon2id), false},
+		{"weaker argon2id", newTestHasher(t, passwords.AlgorithmArgon2id, weakArgon2id), true},
+		{"bcrypt", newTestHasher(t, passwords.AlgorithmBcrypt, testArgon2id), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, SessionPolicy{Mode: SessionPolicyUnlimited})
+			hash, err := tt.hasher.Hash(password)
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			user := loadUser("alice", hash, true, false)
+			f.storeUser(t, user)
+
+			svc := newLoginService(f, EmailConfirmationOptional)
+			if _, err := svc.Login(context.Background(), "alice", password, testDevice); err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+
+			stored := f.storedPassword(t, user)
+			if rehashed := stored != hash; rehashed != tt.rehashed {
+				t.Fatalf("password rehashed = %v, want %v", rehashed, tt.rehashed)
+			}
+			ok, outdated, err := f.hasher.Verify(stored, password)
+			if err != nil || !ok || outdated {
+				t.Errorf("Verify() of stored hash = %v, %v, %v, want true, false, nil", ok, outdated, err)
+			}
+
+			// The new hash keeps working for the next login.
+			if _, err := svc.Login(context.Background(), "alice", password, testDevice); err != nil {
+				t.Errorf("Login() after rehash error = %v", err)
+			}
+		})
+	}
+}
+
+// A password changed between verifying and rehashing it is not overwritten
+// with a hash of the old one.
+func TestLoginServiceRehashKeepsConcurrentChanges(t *testing.T) {
+	f := newFixture(t, SessionPolicy{Mode: SessionPolicyUnlimited})
+	old, err := newTestHasher(t, passwords.AlgorithmBcrypt, testArgon2id).Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	user := loadUser("alice", old, true, false)
+	f.storeUser(t, user)
+
+	changed, err := f.hasher.Hash("battery staple")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if err := memory.NewUserUpdater(f.db).UpdatePassword(context.Background(), user.Id(), old, changed); err != nil {
+		t.Fatalf("UpdatePassword() error = %v", err)
+	}
+
+	svc := newLoginService(f, EmailConfirmationOptional)
+	svc.rehashPassword(context.Background(), user, "correct horse")
+
+	if stored := f.storedPassword(t, user); stored != changed {
+		t.Errorf("stored password = %q, want the concurrently changed %q", stored, changed)
+	}
+}
@@ -0,0 +1,34 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// Names of the mail templates the services render.
+const (
+	mailEmailConfirmation = "email_confirmation"
+	mailPasswordReset     = "password_reset"
+)
+
+// mailSender renders templated mail and hands it to the mailer, so services
+// only supply the template name and its data.
+type mailSender struct {
+	mailer   ports.Mailer
+	renderer ports.MailRenderer
+}
+
+// send renders the template in locale, or the default locale when empty, and
+// sends the result to the given address.
+func (s mailSender) send(ctx context.Context, to entities.Email, template string, locale string, data any) error {
+	mail, err := s.renderer.Render(template, locale, data)
+	if err != nil {
+		return fmt.Errorf("failed to render mail: %w", err)
+	}
+	mail.To = to
+
+	return s.mailer.Send(ctx, mail)
+}
@@ -0,0 +1,126 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+var ErrIncorrectPassword = errors.New("current password is incorrect")
+
+type PasswordChangeService struct {
+	logger *slog.Logger
+
+	userFinder     ports.UserFinder
+	userUpdater    ports.UserUpdater
+	hasher         entities.PasswordHasher
+	passwords      *PasswordFactory
+	sessionService *SessionService
+}
+
+func NewPasswordChangeService(
+	logger *slog.Logger,
+	userFinder ports.UserFinder,
+	userUpdater ports.UserUpdater,
+	hasher entities.PasswordHasher,
+	passwords *PasswordFactory,
+	sessionService *SessionService,
+) *PasswordChangeService {
+	return &PasswordChangeService{
+		logger:         logger,
+		userFinder:     userFinder,
+		userUpdater:    userUpdater,
+		hasher:         hasher,
+		passwords:      passwords,
+		sessionService: sessionService,
+	}
+}
+
+// ChangePassword replaces the password of an authenticated user after
+// checking their current one. With revokeOthers, every session but the one
+// the request was made from is revoked as well.
+func (svc *PasswordChangeService) ChangePassword(
+	ctx context.Context,
+	userId uuid.UUID,
+	session uuid.UUID,
+	currentPassword string,
+	newPassword string,
+	revokeOthers bool,
+) error {
+	user, err := svc.userFinder.FindById(ctx, userId)
+	if err != nil {
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			return ErrInvalidToken
+		}
+
+		svc.logger.ErrorContext(ctx, "Failed to find user", slog.Any("error", err))
+		return ErrInternal
+	}
+
+	if user.IsDeleted() {
+		return ErrInvalidToken
+	}
+
+	ok, _, err := svc.hasher.Verify(user.Password(), currentPassword)
+	if err != nil {
+		svc.logger.ErrorContext(
+			ctx, "Failed to verify password",
+			slog.String("user_id", user.Id().String()),
+			slog.Any("error", err),
+		)
+		return ErrInternal
+	}
+	if !ok {
+		svc.logger.InfoContext(
+			ctx, "Password change failed: incorrect current password",
+			slog.String("user_id", user.Id().String()),
+		)
+		return ErrIncorrectPassword
+	}
+
+	if currentPassword == newPassword {
+		return &entities.ValidationError{
+			Field:   "password",
+			Code:    entities.CodeUnchanged,
+			Message: "should differ from the current password",
+		}
+	}
+
+	passwordObj, err := svc.passwords.New(ctx, newPassword, string(user.Username()), string(user.Email()))
+	if err != nil {
+		return err
+	}
+
+	if err := svc.userUpdater.UpdatePassword(ctx, user.Id(), user.Password(), passwordObj); err != nil {
+		// The password was changed since it was verified above, so the
+		// current password given is not current anymore.
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			svc.logger.InfoContext(
+				ctx, "Password change failed: password changed meanwhile",
+				slog.String("user_id", user.Id().String()),
+			)
+			return ErrIncorrectPassword
+		}
+
+		svc.logger.ErrorContext(
+			ctx, "Failed to store new password",
+			slog.String("user_id", user.Id().String()),
+			slog.Any("error", err),
+		)
+		return ErrInternal
+	}
+
+	svc.logger.InfoContext(ctx, "Password changed", slog.String("user_id", user.Id().String()))
+
+	if revokeOthers {
+		return svc.sessionService.RevokeOtherSessions(ctx, user.Id(), session)
+	}
+	return nil
+}
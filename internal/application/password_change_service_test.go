@@ -0,0 +1,153 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/entities"
+	"github.com/maxdikun/users-api/internal/storage/memory"
+)
+
+func newPasswordChangeService(f *fixture) *PasswordChangeService {
+	return NewPasswordChangeService(
+		discardLogger(),
+		memory.NewUserFinder(f.db),
+		memory.NewUserUpdater(f.db),
+		f.hasher,
+		NewPasswordFactory(discardLogger(), f.hasher, PasswordConfig{Policy: entities.DefaultPasswordPolicy}),
+		f.sessions,
+	)
+}
+
+func TestPasswordChangeServiceChangePassword(t *testing.T) {
+	const current = "correct horse"
+
+	tests := []struct {
+		name            string
+		currentPassword string
+		newPassword     string
+		revokeOthers    bool
+		wantErr         error
+		wantCode        entities.ValidationCode
+	}{
+		{name: "changed", currentPassword: current, newPassword: "battery staple 9"},
+		{name: "changed and others revoked", currentPassword: current, newPassword: "battery staple 9", revokeOthers: true},
+		{name: "wrong current password", currentPassword: "wrong horse", newPassword: "battery staple 9", wantErr: ErrIncorrectPassword},
+		{name: "unchanged", currentPassword: current, newPassword: current, wantCode: entities.CodeUnchanged},
+		{name: "against the policy", currentPassword: current, newPassword: "short", wantCode: entities.CodeTooShort},
+		{name: "containing the username", currentPassword: current, newPassword: "alice-staple-9", wantCode: entities.CodeContainsUserInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, SessionPolicy{Mode: SessionPolicyUnlimited})
+			user := f.addUser(t, "alice", current)
+			f.login(t, user)
+			f.login(t, user)
+			keep := f.activeSessions(t, user)[0].Id()
+			before := f.storedPassword(t, user)
+
+			err := newPasswordChangeService(f).ChangePassword(
+				context.Background(), user.Id(), keep, tt.currentPassword, tt.newPassword, tt.revokeOthers,
+			)
+
+			switch {
+			case tt.wantCode != "":
+				errs := entities.ValidationErrors(err)
+				if len(errs) == 0 || errs[0].Code != tt.wantCode {
+					t.Fatalf("ChangePassword() error = %v, want a %s validation error", err, tt.wantCode)
+				}
+			case !errors.Is(err, tt.wantErr):
+				t.Fatalf("ChangePassword() error = %v, want %v", err, tt.wantErr)
+			}
+
+			stored := f.storedPassword(t, user)
+			if err != nil {
+				if stored != before {
+					t.Error("ChangePassword() failed but replaced the password")
+				}
+				if sessions := f.activeSessions(t, user); len(sessions) != 2 {
+					t.Errorf("active sessions = %d, want 2 after a failed change", len(sessions))
+				}
+				return
+			}
+
+			if ok, _, _ := f.hasher.Verify(stored, tt.newPassword); !ok {
+				t.Error("stored password does not match the new password")
+			}
+			if ok, _, _ := f.hasher.Verify(stored, tt.currentPassword); ok {
+				t.Error("stored password still matches the old password")
+			}
+
+			sessions := f.activeSessions(t, user)
+			wantSessions := 2
+			if tt.revokeOthers {
+				wantSessions = 1
+			}
+			if len(sessions) != wantSessions {
+				t.Fatalf("active sessions = %d, want %d", len(sessions), wantSessions)
+			}
+			if !slices.ContainsFunc(sessions, func(s entities.Session) bool { return s.Id() == keep }) {
+				t.Error("ChangePassword() revoked the session it was made from")
+			}
+		})
+	}
+}
+
+func TestPasswordChangeServiceRejectsUnknownUsers(t *testing.T) {
+	f := newFixture(t, SessionPolicy{Mode: SessionPolicyUnlimited})
+	hash, err := f.hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	deleted := loadUser("deleted", hash, true, true)
+	f.storeUser(t, deleted)
+	svc := newPasswordChangeService(f)
+
+	for name, id := range map[string]uuid.UUID{"unknown": uuid.New(), "deleted": deleted.Id()} {
+		err := svc.ChangePassword(context.Background(), id, uuid.New(), "correct horse", "battery staple 9", false)
+		if !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("ChangePassword() of %s user error = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
+
+// Two changes racing with the same current password cannot both succeed;
+// the second one's current password is stale once the first is stored.
+func TestPasswordChangeServiceConcurrentChange(t *testing.T) {
+	f := newFixture(t, SessionPolicy{Mode: SessionPolicyUnlimited})
+	user := f.addUser(t, "alice", "correct horse")
+
+	changed, err := f.hasher.Hash("battery staple 9")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if err := memory.NewUserUpdater(f.db).UpdatePassword(context.Background(), user.Id(), user.Password(), changed); err != nil {
+		t.Fatalf("UpdatePassword() error = %v", err)
+	}
+	// The change below looked the user up before the one above was stored.
+	svc := newPasswordChangeService(f)
+	svc.userFinder = staleUserFinder{UserFinder: memory.NewUserFinder(f.db), user: user}
+
+	err = svc.ChangePassword(context.Background(), user.Id(), uuid.New(), "correct horse", "tr0ub4dour and 3", false)
+	if !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("ChangePassword() with stale current password error = %v, want ErrIncorrectPassword", err)
+	}
+	if stored := f.storedPassword(t, user); stored != changed {
+		t.Error("ChangePassword() overwrote a concurrent change")
+	}
+}
+
+// staleUserFinder returns user as it was before a concurrent change.
+type staleUserFinder struct {
+	*memory.UserFinder
+	user entities.User
+}
+
+func (f staleUserFinder) FindById(context.Context, uuid.UUID) (entities.User, error) {
+	return f.user, nil
+}
@@ -0,0 +1,107 @@
+package application
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// BreachPolicy decides what happens to a password found in the breach
+// corpus.
+type BreachPolicy string
+
+const (
+	// BreachPolicyReject refuses breached passwords.
+	BreachPolicyReject BreachPolicy = "reject"
+	// BreachPolicyWarn accepts breached passwords but logs a warning.
+	BreachPolicyWarn BreachPolicy = "warn"
+)
+
+type PasswordConfig struct {
+	Policy entities.PasswordPolicy
+	// BreachChecker is nil when no breach corpus is configured.
+	BreachChecker ports.BreachedPasswordChecker
+	BreachPolicy  BreachPolicy
+	// BreachMinCount is how often a password has to appear in the corpus
+	// to count as breached.
+	BreachMinCount int
+}
+
+// PasswordFactory turns the plain text passwords users choose on
+// registration, reset and change into password hashes, enforcing the
+// password rules and the breach policy on the way.
+type PasswordFactory struct {
+	logger *slog.Logger
+
+	policy         entities.PasswordPolicy
+	hasher         entities.PasswordHasher
+	breachChecker  ports.BreachedPasswordChecker
+	breachPolicy   BreachPolicy
+	breachMinCount int
+}
+
+func NewPasswordFactory(logger *slog.Logger, hasher entities.PasswordHasher, config PasswordConfig) *PasswordFactory {
+	return &PasswordFactory{
+		logger:         logger,
+		policy:         config.Policy,
+		hasher:         hasher,
+		breachChecker:  config.BreachChecker,
+		breachPolicy:   config.BreachPolicy,
+		breachMinCount: max(config.BreachMinCount, 1),
+	}
+}
+
+// New validates password for the user with username and email and hashes
+// it. It returns validation errors as they are and ErrInternal for
+// everything else. A failing breach lookup is logged but lets the password
+// through, so an unreadable corpus does not lock users out.
+func (f *PasswordFactory) New(ctx context.Context, password string, username string, email string) (entities.Password, error) {
+	localPart, _, _ := strings.Cut(email, "@")
+	hash, err := entities.NewPassword(password, f.policy, f.hasher, username, localPart)
+	if err != nil {
+		if len(entities.ValidationErrors(err)) > 0 {
+			return "", err
+		}
+
+		f.logger.ErrorContext(ctx, "Failed to generate a password", slog.Any("error", err))
+		return "", ErrInternal
+	}
+
+	// Checked after the policy so its errors are reported first; a breached
+	// password only costs a wasted hash.
+	if err := f.checkBreached(ctx, password); err != nil {
+		return "", err
+	}
+
+	return hash, nil
+}
+
+func (f *PasswordFactory) checkBreached(ctx context.Context, password string) error {
+	if f.breachChecker == nil {
+		return nil
+	}
+
+	count, err := f.breachChecker.BreachCount(ctx, password)
+	if err != nil {
+		f.logger.ErrorContext(ctx, "Breached password lookup failed", slog.Any("error", err))
+		return nil
+	}
+	if count < f.breachMinCount {
+		return nil
+	}
+
+	if f.breachPolicy == BreachPolicyWarn {
+		f.logger.WarnContext(ctx, "Accepted a breached password", slog.Int("breach_count", count))
+		return nil
+	}
+
+	f.logger.InfoContext(ctx, "Rejected a breached password", slog.Int("breach_count", count))
+	return &entities.ValidationError{
+		Field:   "password",
+		Code:    entities.CodeBreached,
+		Message: "appears in a known data breach, choose another one",
+	}
+}
@@ -0,0 +1,161 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/maxdikun/users-api/internal/entities"
+	"github.com/maxdikun/users-api/internal/passwords"
+	"github.com/maxdikun/users-api/internal/strength"
+)
+
+// stubBreachChecker reports the breach counts it holds, and err for every
+// other password when err is set.
+type stubBreachChecker struct {
+	counts map[string]int
+	err    error
+}
+
+func (c stubBreachChecker) BreachCount(_ context.Context, password string) (int, error) {
+	if count, ok := c.counts[password]; ok {
+		return count, nil
+	}
+	return 0, c.err
+}
+
+func TestPasswordFactoryNew(t *testing.T) {
+	policy := entities.PasswordPolicy{
+		MinLength:        8,
+		MaxLength:        64,
+		RequiredClasses:  []entities.CharacterClass{entities.ClassDigit},
+		MinClasses:       2,
+		BannedSubstrings: []string{"acme"},
+		BanUserInputs:    true,
+		MinStrength:      3,
+		Estimator:        strength.Estimator{},
+	}
+	breaches := stubBreachChecker{
+		counts: map[string]int{"kX9#vQ2!mZ7$wL4p": 3, "Zq7!rT4#nW8$yP2m": 1},
+		err:    errors.New("corpus unreadable"),
+	}
+
+	tests := []struct {
+		name      string
+		config    PasswordConfig
+		password  string
+		wantCodes []entities.ValidationCode
+	}{
+		{
+			name:     "strong",
+			config:   PasswordConfig{Policy: policy},
+			password: "correct horse battery 9",
+		},
+		{
+			name:      "too short, missing a digit and too weak",
+			config:    PasswordConfig{Policy: policy},
+			password:  "Secret",
+			wantCodes: []entities.ValidationCode{entities.CodeTooShort, entities.CodeMissingCharacterClass, entities.CodeTooWeak},
+		},
+		{
+			name:      "too long",
+			config:    PasswordConfig{Policy: policy},
+			password:  "correct horse battery staple 9 correct horse battery staple 9 and more",
+			wantCodes: []entities.ValidationCode{entities.CodeTooLong},
+		},
+		{
+			name:      "too few classes",
+			config:    PasswordConfig{Policy: policy},
+			password:  "4815162342108",
+			wantCodes: []entities.ValidationCode{entities.CodeTooFewCharacterClasses},
+		},
+		{
+			name:      "banned substring",
+			config:    PasswordConfig{Policy: policy},
+			password:  "correct ACME battery 9",
+			wantCodes: []entities.ValidationCode{entities.CodeBannedSubstring},
+		},
+		{
+			name:      "username",
+			config:    PasswordConfig{Policy: policy},
+			password:  "correct Wolfgang battery 9",
+			wantCodes: []entities.ValidationCode{entities.CodeContainsUserInput},
+		},
+		{
+			name:      "local part of the email address",
+			config:    PasswordConfig{Policy: policy},
+			password:  "correct amadeus battery 9",
+			wantCodes: []entities.ValidationCode{entities.CodeContainsUserInput},
+		},
+		{
+			name:      "too weak",
+			config:    PasswordConfig{Policy: policy},
+			password:  "Password123",
+			wantCodes: []entities.ValidationCode{entities.CodeTooWeak},
+		},
+		{
+			name:     "policy without strength",
+			config:   PasswordConfig{Policy: entities.DefaultPasswordPolicy},
+			password: "Password123",
+		},
+		{
+			name:      "breached",
+			config:    PasswordConfig{Policy: policy, BreachChecker: breaches, BreachPolicy: BreachPolicyReject},
+			password:  "kX9#vQ2!mZ7$wL4p",
+			wantCodes: []entities.ValidationCode{entities.CodeBreached},
+		},
+		{
+			name:     "breached with warnings",
+			config:   PasswordConfig{Policy: policy, BreachChecker: breaches, BreachPolicy: BreachPolicyWarn},
+			password: "kX9#vQ2!mZ7$wL4p",
+		},
+		{
+			name:      "breached once",
+			config:    PasswordConfig{Policy: policy, BreachChecker: breaches, BreachPolicy: BreachPolicyReject},
+			password:  "Zq7!rT4#nW8$yP2m",
+			wantCodes: []entities.ValidationCode{entities.CodeBreached},
+		},
+		{
+			name: "breached less than the minimum",
+			config: PasswordConfig{
+				Policy: policy, BreachChecker: breaches, BreachPolicy: BreachPolicyReject, BreachMinCount: 2,
+			},
+			password: "Zq7!rT4#nW8$yP2m",
+		},
+		{
+			name:     "breach lookup failing",
+			config:   PasswordConfig{Policy: policy, BreachChecker: breaches, BreachPolicy: BreachPolicyReject},
+			password: "correct horse battery 9",
+		},
+		{
+			name:      "breached and against the policy",
+			config:    PasswordConfig{Policy: entities.PasswordPolicy{MinLength: 20}, BreachChecker: breaches},
+			password:  "kX9#vQ2!mZ7$wL4p",
+			wantCodes: []entities.ValidationCode{entities.CodeTooShort},
+		},
+	}
+
+	hasher := newTestHasher(t, passwords.AlgorithmArgon2id, testArgon2id)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := NewPasswordFactory(discardLogger(), hasher, tt.config)
+			hash, err := factory.New(context.Background(), tt.password, "wolfgang", "amadeus@example.com")
+
+			var codes []entities.ValidationCode
+			for _, e := range entities.ValidationErrors(err) {
+				codes = append(codes, e.Code)
+			}
+			if !slices.Equal(codes, tt.wantCodes) {
+				t.Fatalf("New() error = %v, want codes %v", err, tt.wantCodes)
+			}
+			if err != nil {
+				return
+			}
+
+			if ok, outdated, err := hasher.Verify(hash, tt.password); !ok || outdated || err != nil {
+				t.Errorf("Verify() of the new hash = %v, %v, %v, want true, false, nil", ok, outdated, err)
+			}
+		})
+	}
+}
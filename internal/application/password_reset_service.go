@@ -0,0 +1,260 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type PasswordResetConfig struct {
+	TokenDuration time.Duration
+	// RequestCooldown is how long a user has to wait before another reset
+	// mail is sent.
+	RequestCooldown time.Duration
+	// LinkTemplate is the URL mailed to users, with "{token}" replaced by the
+	// reset token.
+	LinkTemplate string
+	// TokenPepper is the secret the hash key of reset tokens is derived
+	// from. It may be shared with other kinds of token.
+	TokenPepper []byte
+}
+
+// passwordResetMail is the data of the password_reset template.
+type passwordResetMail struct {
+	Username  string
+	Link      string
+	ExpiresIn time.Duration
+}
+
+type PasswordResetService struct {
+	logger *slog.Logger
+
+	userFinder     ports.UserFinder
+	userUpdater    ports.UserUpdater
+	tokenAppender  ports.ActionTokenAppender
+	tokenFinder    ports.ActionTokenFinder
+	tokenConsumer  ports.ActionTokenConsumer
+	cooldowns      ports.ActionCooldownClaimer
+	passwords      *PasswordFactory
+	sessionService *SessionService
+	mailSender     mailSender
+	tasks          *TaskQueue
+
+	tokenDuration   time.Duration
+	requestCooldown time.Duration
+	linkTemplate    string
+	tokenHasher     tokenHasher
+}
+
+func NewPasswordResetService(
+	logger *slog.Logger,
+	userFinder ports.UserFinder,
+	userUpdater ports.UserUpdater,
+	tokenAppender ports.ActionTokenAppender,
+	tokenFinder ports.ActionTokenFinder,
+	tokenConsumer ports.ActionTokenConsumer,
+	cooldowns ports.ActionCooldownClaimer,
+	passwords *PasswordFactory,
+	sessionService *SessionService,
+	mailer ports.Mailer,
+	renderer ports.MailRenderer,
+	tasks *TaskQueue,
+	config PasswordResetConfig,
+) *PasswordResetService {
+	return &PasswordResetService{
+		logger:          logger,
+		userFinder:      userFinder,
+		userUpdater:     userUpdater,
+		tokenAppender:   tokenAppender,
+		tokenFinder:     tokenFinder,
+		tokenConsumer:   tokenConsumer,
+		cooldowns:       cooldowns,
+		passwords:       passwords,
+		sessionService:  sessionService,
+		mailSender:      mailSender{mailer: mailer, renderer: renderer},
+		tasks:           tasks,
+		tokenDuration:   config.TokenDuration,
+		requestCooldown: config.RequestCooldown,
+		linkTemplate:    config.LinkTemplate,
+		tokenHasher:     newActionTokenHasher(config.TokenPepper, entities.ActionPasswordReset),
+	}
+}
+
+// RequestReset queues a reset link in locale to the owner of the address. It
+// never fails and returns before looking the address up: unknown and deleted
+// users, the request cooldown and even internal errors are only logged by the
+// queued work, so the caller cannot tell whether the address is registered.
+func (svc *PasswordResetService) RequestReset(ctx context.Context, email string, locale string) {
+	emailObj, err := entities.NewEmail(email)
+	if err != nil {
+		return
+	}
+
+	svc.tasks.EnqueueFor(ctx, "request_reset", strings.ToLower(string(emailObj)), func(ctx context.Context) {
+		svc.requestReset(ctx, emailObj, locale)
+	})
+}
+
+func (svc *PasswordResetService) requestReset(ctx context.Context, email entities.Email, locale string) {
+	user, err := svc.userFinder.FindByEmail(ctx, email)
+	if err != nil {
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			svc.logger.InfoContext(ctx, "Password reset skipped: unknown email")
+			return
+		}
+
+		svc.logger.ErrorContext(ctx, "Failed to find user", slog.Any("error", err))
+		return
+	}
+
+	if user.IsDeleted() {
+		svc.logger.InfoContext(
+			ctx, "Password reset skipped: user deleted",
+			slog.String("user_id", user.Id().String()),
+		)
+		return
+	}
+
+	claimed, err := svc.cooldowns.ClaimActionCooldown(
+		ctx, user.Id(), entities.ActionPasswordReset, time.Now(), svc.requestCooldown,
+	)
+	if err != nil {
+		svc.logger.ErrorContext(ctx, "Failed to claim reset cooldown", slog.Any("error", err))
+		return
+	}
+	if !claimed {
+		svc.logger.InfoContext(
+			ctx, "Password reset skipped: cooldown",
+			slog.String("user_id", user.Id().String()),
+		)
+		return
+	}
+
+	token, err := generateRandomString(32)
+	if err != nil {
+		svc.logger.ErrorContext(ctx, "Generating reset token failed", slog.Any("error", err))
+		return
+	}
+
+	actionToken := entities.NewActionToken(
+		user.Id(), entities.ActionPasswordReset, svc.tokenHasher.hash(token), svc.tokenDuration,
+	)
+	if err := svc.tokenAppender.AppendActionToken(ctx, actionToken); err != nil {
+		svc.logger.ErrorContext(
+			ctx, "Failed to store reset token",
+			slog.String("user_id", user.Id().String()),
+			slog.Any("error", err),
+		)
+		return
+	}
+
+	err = svc.mailSender.send(ctx, user.Email(), mailPasswordReset, locale, passwordResetMail{
+		Username:  string(user.Username()),
+		Link:      strings.ReplaceAll(svc.linkTemplate, "{token}", token),
+		ExpiresIn: svc.tokenDuration,
+	})
+	if err != nil {
+		svc.logger.ErrorContext(
+			ctx, "Failed to send reset mail",
+			slog.String("user_id", user.Id().String()),
+			slog.Any("error", err),
+		)
+		return
+	}
+
+	svc.logger.InfoContext(ctx, "Password reset mail sent", slog.String("user_id", user.Id().String()))
+}
+
+// ResetPassword consumes a reset token, replaces the password of its user,
+// revokes all of their sessions and invalidates their other reset tokens. The
+// new password is validated against the user before the token is consumed,
+// so a rejected password does not burn the link.
+func (svc *PasswordResetService) ResetPassword(ctx context.Context, token string, password string) error {
+	tokenHash := svc.tokenHasher.hash(token)
+
+	actionToken, err := svc.tokenFinder.FindUsableActionToken(ctx, entities.ActionPasswordReset, tokenHash, time.Now())
+	if err != nil {
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			return ErrInvalidActionToken
+		}
+
+		svc.logger.ErrorContext(ctx, "Failed to find reset token", slog.Any("error", err))
+		return ErrInternal
+	}
+
+	user, err := svc.userFinder.FindById(ctx, actionToken.User())
+	if err != nil {
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			return ErrInvalidActionToken
+		}
+
+		svc.logger.ErrorContext(ctx, "Failed to find user", slog.Any("error", err))
+		return ErrInternal
+	}
+
+	if user.IsDeleted() {
+		return ErrInvalidActionToken
+	}
+
+	passwordObj, err := svc.passwords.New(ctx, password, string(user.Username()), string(user.Email()))
+	if err != nil {
+		return err
+	}
+
+	// A concurrent reset with the same link may have won in the meantime.
+	_, err = svc.tokenConsumer.ConsumeActionToken(ctx, entities.ActionPasswordReset, tokenHash, time.Now())
+	if err != nil {
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			return ErrInvalidActionToken
+		}
+
+		svc.logger.ErrorContext(ctx, "Failed to consume reset token", slog.Any("error", err))
+		return ErrInternal
+	}
+
+	if err := svc.userUpdater.UpdatePassword(ctx, user.Id(), user.Password(), passwordObj); err != nil {
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			svc.logger.InfoContext(
+				ctx, "Password reset failed: password changed meanwhile",
+				slog.String("user_id", user.Id().String()),
+			)
+			return ErrInvalidActionToken
+		}
+
+		svc.logger.ErrorContext(
+			ctx, "Failed to store new password",
+			slog.String("user_id", user.Id().String()),
+			slog.Any("error", err),
+		)
+		return ErrInternal
+	}
+
+	if err := svc.sessionService.RevokeAllSessions(ctx, user.Id()); err != nil {
+		return err
+	}
+
+	// Links mailed for earlier requests would otherwise still reset the new
+	// password.
+	_, err = svc.tokenConsumer.ConsumeUserActionTokens(ctx, user.Id(), entities.ActionPasswordReset, time.Now())
+	if err != nil {
+		svc.logger.ErrorContext(
+			ctx, "Failed to invalidate remaining reset tokens",
+			slog.String("user_id", user.Id().String()),
+			slog.Any("error", err),
+		)
+		return ErrInternal
+	}
+
+	svc.logger.InfoContext(ctx, "Password reset", slog.String("user_id", user.Id().String()))
+	return nil
+}
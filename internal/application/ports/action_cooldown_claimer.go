@@ -0,0 +1,24 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type ActionCooldownClaimer interface {
+	// ClaimActionCooldown atomically records that the user was mailed for the
+	// purpose at the given time, unless that was already recorded less than
+	// cooldown before. It reports whether the claim was recorded, so of
+	// concurrent callers at most one per cooldown gets true.
+	ClaimActionCooldown(
+		ctx context.Context,
+		user uuid.UUID,
+		purpose entities.ActionPurpose,
+		at time.Time,
+		cooldown time.Duration,
+	) (bool, error)
+}
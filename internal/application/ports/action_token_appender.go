@@ -0,0 +1,11 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type ActionTokenAppender interface {
+	AppendActionToken(ctx context.Context, token entities.ActionToken) error
+}
@@ -0,0 +1,30 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type ActionTokenConsumer interface {
+	// ConsumeActionToken atomically marks the token as used at the given time
+	// and returns it. Unknown, already used and expired tokens all yield a
+	// NotFoundError, so each token can be consumed at most once.
+	ConsumeActionToken(
+		ctx context.Context,
+		purpose entities.ActionPurpose,
+		tokenHash string,
+		at time.Time,
+	) (entities.ActionToken, error)
+	// ConsumeUserActionTokens marks every unused token of the user for the
+	// purpose as used at the given time and reports how many were affected.
+	ConsumeUserActionTokens(
+		ctx context.Context,
+		user uuid.UUID,
+		purpose entities.ActionPurpose,
+		at time.Time,
+	) (int, error)
+}
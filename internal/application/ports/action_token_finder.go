@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type ActionTokenFinder interface {
+	// FindLatestActionToken returns the most recently created token of the
+	// user for the purpose, used or not.
+	FindLatestActionToken(ctx context.Context, user uuid.UUID, purpose entities.ActionPurpose) (entities.ActionToken, error)
+	// FindUsableActionToken returns the token with the hash for the purpose
+	// without consuming it. Like ConsumeActionToken it yields a
+	// NotFoundError for unknown, used and expired tokens.
+	FindUsableActionToken(
+		ctx context.Context,
+		purpose entities.ActionPurpose,
+		tokenHash string,
+		at time.Time,
+	) (entities.ActionToken, error)
+}
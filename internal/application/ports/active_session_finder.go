@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type ActiveSessionFinder interface {
+	// FindActiveByUser returns the sessions of a user that are neither
+	// revoked nor expired at the given moment, oldest first.
+	FindActiveByUser(ctx context.Context, user uuid.UUID, now time.Time) ([]entities.Session, error)
+}
@@ -0,0 +1,11 @@
+package ports
+
+import (
+	"context"
+)
+
+type BreachedPasswordChecker interface {
+	// BreachCount reports how often password appears in the breach corpus,
+	// zero if it does not.
+	BreachCount(ctx context.Context, password string) (int, error)
+}
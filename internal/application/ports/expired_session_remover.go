@@ -0,0 +1,12 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+type ExpiredSessionRemover interface {
+	// RemoveExpiredSessions deletes at most limit sessions that expired
+	// before the given moment and reports how many were deleted.
+	RemoveExpiredSessions(ctx context.Context, before time.Time, limit int) (int, error)
+}
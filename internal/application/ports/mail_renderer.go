@@ -0,0 +1,12 @@
+package ports
+
+import (
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// MailRenderer builds the subject and bodies of a mail from the named
+// template, preferring the variant for locale when there is one. The
+// recipient of the returned mail is left empty.
+type MailRenderer interface {
+	Render(name string, locale string, data any) (entities.Mail, error)
+}
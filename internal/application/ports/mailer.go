@@ -0,0 +1,11 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type Mailer interface {
+	Send(ctx context.Context, mail entities.Mail) error
+}
@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type RotatedTokenFinder interface {
+	// FindByRotatedToken returns the session a token with the given hash was
+	// issued for before it was rotated away.
+	FindByRotatedToken(ctx context.Context, tokenHash string) (entities.Session, error)
+}
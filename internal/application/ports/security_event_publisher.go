@@ -0,0 +1,11 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type SecurityEventPublisher interface {
+	PublishSecurityEvent(ctx context.Context, event entities.SecurityEvent) error
+}
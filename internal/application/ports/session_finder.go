@@ -7,5 +7,5 @@ import (
 )
 
 type SessionFinder interface {
-	Find(ctx context.Context, token string) (entities.Session, error)
+	Find(ctx context.Context, tokenHash string) (entities.Session, error)
 }
@@ -0,0 +1,20 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SessionRevoker interface {
+	// RevokeSession revokes a single session of the user. It returns a
+	// NotFoundError if the user has no such session or it is already revoked.
+	RevokeSession(ctx context.Context, user uuid.UUID, id uuid.UUID, at time.Time) error
+	// RevokeUserSessions revokes every session of the user that is not
+	// revoked yet and reports how many were affected.
+	RevokeUserSessions(ctx context.Context, user uuid.UUID, at time.Time) (int, error)
+	// RevokeOtherSessions revokes every live session of the user except keep
+	// and reports how many were affected.
+	RevokeOtherSessions(ctx context.Context, user uuid.UUID, keep uuid.UUID, at time.Time) (int, error)
+}
@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type SessionRotator interface {
+	// RotateSession stores the token, refresh and expiry times of a session
+	// that went through Refresh, but only while the stored session is still
+	// live and holds the token that was replaced. Otherwise the token was
+	// already rotated or revoked concurrently and a NotFoundError is returned.
+	RotateSession(ctx context.Context, session entities.Session) error
+}
@@ -3,10 +3,13 @@ package ports
 import (
 	"context"
 
+	"github.com/google/uuid"
+
 	"github.com/maxdikun/users-api/internal/entities"
 )
 
 type UserFinder interface {
+	FindById(ctx context.Context, id uuid.UUID) (entities.User, error)
 	FindByUsername(ctx context.Context, username entities.Username) (entities.User, error)
 	FindByEmail(ctx context.Context, email entities.Email) (entities.User, error)
 }
@@ -0,0 +1,24 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// UserUpdater changes single fields of a stored user, so that concurrent
+// changes of other fields are never overwritten with stale values.
+type UserUpdater interface {
+	// ConfirmEmail marks the email address of the user as confirmed at the
+	// given time, unless it already is. It returns a NotFoundError for
+	// unknown users.
+	ConfirmEmail(ctx context.Context, id uuid.UUID, at time.Time) error
+	// UpdatePassword replaces the password hash of the user, but only while
+	// the stored hash still equals oldHash. Otherwise the password was changed
+	// concurrently, or the user does not exist, and a NotFoundError is
+	// returned.
+	UpdatePassword(ctx context.Context, id uuid.UUID, oldHash, newHash entities.Password) error
+}
@@ -16,30 +16,38 @@ var (
 )
 
 type RegisterService struct {
-	logger   *slog.Logger
-	appender ports.UserAppender
+	logger        *slog.Logger
+	appender      ports.UserAppender
+	passwords     *PasswordFactory
+	confirmations *EmailConfirmationService
+	tasks         *TaskQueue
 }
 
-func NewRegisterService(logger *slog.Logger, appender ports.UserAppender) *RegisterService {
+func NewRegisterService(
+	logger *slog.Logger,
+	appender ports.UserAppender,
+	passwords *PasswordFactory,
+	confirmations *EmailConfirmationService,
+	tasks *TaskQueue,
+) *RegisterService {
 	return &RegisterService{
-		logger:   logger,
-		appender: appender,
+		logger:        logger,
+		appender:      appender,
+		passwords:     passwords,
+		confirmations: confirmations,
+		tasks:         tasks,
 	}
 }
 
-func (svc *RegisterService) Register(ctx context.Context, username string, password string, email string) error {
+func (svc *RegisterService) Register(ctx context.Context, username string, password string, email string, locale string) error {
 	svc.logger.DebugContext(ctx, "RegisterService.Register called")
 
 	usernameObj, usernameErr := entities.NewUsername(username)
 	emailObj, emailErr := entities.NewEmail(email)
-	passwordObj, passwordErr := entities.NewPassword(password)
+	passwordObj, passwordErr := svc.passwords.New(ctx, password, username, email)
 
-	if passwordErr != nil {
-		var vErr *entities.ValidationError
-		if !errors.As(passwordErr, &vErr) {
-			svc.logger.ErrorContext(ctx, "Failed to generate a password", slog.Any("error", passwordErr))
-			return ErrInternal
-		}
+	if errors.Is(passwordErr, ErrInternal) {
+		return ErrInternal
 	}
 
 	err := errors.Join(usernameErr, emailErr, passwordErr)
@@ -85,5 +93,12 @@ func (svc *RegisterService) Register(ctx context.Context, username string, passw
 
 	svc.logger.InfoContext(ctx, "Successfully registered a user")
 
+	// The account exists at this point; a lost confirmation mail can be
+	// resent, so it must not fail or hold up the registration.
+	svc.tasks.Enqueue(ctx, "send_confirmation", func(ctx context.Context) {
+		// SendConfirmation logs its own failures.
+		_ = svc.confirmations.SendConfirmation(ctx, user, locale)
+	})
+
 	return nil
 }
@@ -0,0 +1,39 @@
+package application
+
+import "github.com/maxdikun/users-api/internal/entities"
+
+type SessionPolicyMode string
+
+const (
+	// SessionPolicyUnlimited lets a user keep any number of sessions.
+	SessionPolicyUnlimited SessionPolicyMode = "unlimited"
+	// SessionPolicyLimited keeps at most MaxSessions sessions per user and
+	// revokes the oldest ones when a new session goes over the limit.
+	SessionPolicyLimited SessionPolicyMode = "limited"
+	// SessionPolicySingle keeps one session per user: logging in revokes
+	// every other session of the user.
+	SessionPolicySingle SessionPolicyMode = "single"
+)
+
+type SessionPolicy struct {
+	Mode        SessionPolicyMode
+	MaxSessions int
+}
+
+// evictions returns the sessions that have to be revoked to keep the user
+// within the policy once a new session is added. active must hold the other
+// live sessions of the user, oldest first.
+func (p SessionPolicy) evictions(active []entities.Session) []entities.Session {
+	switch p.Mode {
+	case SessionPolicySingle:
+		return active
+	case SessionPolicyLimited:
+		excess := len(active) + 1 - max(p.MaxSessions, 1)
+		if excess <= 0 {
+			return nil
+		}
+		return active[:excess]
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,83 @@
+package application
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+)
+
+// SessionReaper periodically deletes expired sessions so they do not pile up
+// in storage. Expired sessions are already rejected by SessionService, the
+// reaper only reclaims space.
+type SessionReaper struct {
+	logger  *slog.Logger
+	remover ports.ExpiredSessionRemover
+
+	interval  time.Duration
+	batchSize int
+}
+
+func NewSessionReaper(
+	logger *slog.Logger,
+	remover ports.ExpiredSessionRemover,
+	interval time.Duration,
+	batchSize int,
+) *SessionReaper {
+	return &SessionReaper{
+		logger:    logger,
+		remover:   remover,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Run reaps expired sessions every interval until ctx is cancelled.
+func (r *SessionReaper) Run(ctx context.Context) {
+	r.logger.InfoContext(
+		ctx, "Session reaper started",
+		slog.Duration("interval", r.interval),
+		slog.Int("batch_size", r.batchSize),
+	)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.Reap(ctx)
+
+		select {
+		case <-ctx.Done():
+			r.logger.InfoContext(ctx, "Session reaper stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reap deletes expired sessions in batches until none are left or ctx is
+// cancelled, and returns how many were deleted.
+func (r *SessionReaper) Reap(ctx context.Context) int {
+	now := time.Now()
+	total := 0
+
+	for ctx.Err() == nil {
+		removed, err := r.remover.RemoveExpiredSessions(ctx, now, r.batchSize)
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Failed to remove expired sessions", slog.Any("error", err))
+			break
+		}
+
+		total += removed
+		if removed < r.batchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		r.logger.InfoContext(ctx, "Removed expired sessions", slog.Int("count", total))
+	}
+
+	return total
+}
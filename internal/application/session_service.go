@@ -3,7 +3,10 @@ package application
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -11,24 +14,103 @@ import (
 
 	"github.com/maxdikun/users-api/internal/application/ports"
 	"github.com/maxdikun/users-api/internal/entities"
+	"github.com/maxdikun/users-api/internal/keys"
 )
 
 var (
-	ErrUserAlreadyLoggedIn = errors.New("user already logged in")
-	ErrInvalidToken        = errors.New("invalid token was provided")
+	ErrInvalidToken    = errors.New("invalid token was provided")
+	ErrSessionNotFound = errors.New("session not found")
 )
 
 type SessionService struct {
 	logger *slog.Logger
 
-	sessionAppender ports.SessionAppender
-	sessionFinder   ports.SessionFinder
-	sessionUpdater  ports.SessionUpdater
+	userFinder          ports.UserFinder
+	sessionAppender     ports.SessionAppender
+	sessionFinder       ports.SessionFinder
+	rotatedTokenFinder  ports.RotatedTokenFinder
+	activeSessionFinder ports.ActiveSessionFinder
+	sessionRotator      ports.SessionRotator
+	sessionRevoker      ports.SessionRevoker
+	securityEvents      ports.SecurityEventPublisher
 
 	maxTokenRetries     int
 	sessionDuration     time.Duration
 	accessTokenDuration time.Duration
-	tokenSecret         string
+	signingKeys         *keys.Ring
+	issuer              string
+	audience            []string
+	clientId            string
+	scopes              []string
+	claims              ClaimsFunc
+	restrictUnconfirmed bool
+	tokenHasher         tokenHasher
+	policy              SessionPolicy
+}
+
+type SessionConfig struct {
+	MaxTokenRetries     int
+	SessionDuration     time.Duration
+	AccessTokenDuration time.Duration
+	// SigningKeys signs access tokens with its active key and verifies them
+	// against every key it publishes.
+	SigningKeys *keys.Ring
+	// Issuer and Audience are stamped into every access token and required
+	// when verifying one.
+	Issuer   string
+	Audience []string
+	// ClientId is the "client_id" of access tokens. Tokens are issued to the
+	// first-party client logging users in, not to third parties, so it is
+	// the same for every token.
+	ClientId string
+	// Scopes are granted to every access token.
+	Scopes []string
+	// Claims adds custom claims to access tokens; it may be nil.
+	Claims ClaimsFunc
+	// RestrictUnconfirmed withholds Scopes from users whose email address is
+	// not confirmed yet.
+	RestrictUnconfirmed bool
+	// RefreshTokenPepper keys the hash refresh tokens are stored under.
+	// Changing it invalidates every existing session.
+	RefreshTokenPepper []byte
+	Policy             SessionPolicy
+}
+
+func NewSessionService(
+	logger *slog.Logger,
+	userFinder ports.UserFinder,
+	sessionAppender ports.SessionAppender,
+	sessionFinder ports.SessionFinder,
+	rotatedTokenFinder ports.RotatedTokenFinder,
+	activeSessionFinder ports.ActiveSessionFinder,
+	sessionRotator ports.SessionRotator,
+	sessionRevoker ports.SessionRevoker,
+	securityEvents ports.SecurityEventPublisher,
+	config SessionConfig,
+) *SessionService {
+	return &SessionService{
+		logger:              logger,
+		userFinder:          userFinder,
+		sessionAppender:     sessionAppender,
+		sessionFinder:       sessionFinder,
+		rotatedTokenFinder:  rotatedTokenFinder,
+		activeSessionFinder: activeSessionFinder,
+		sessionRotator:      sessionRotator,
+		sessionRevoker:      sessionRevoker,
+		securityEvents:      securityEvents,
+		maxTokenRetries:     config.MaxTokenRetries,
+		sessionDuration:     config.SessionDuration,
+		accessTokenDuration: config.AccessTokenDuration,
+		signingKeys:         config.SigningKeys,
+		issuer:              config.Issuer,
+		audience:            config.Audience,
+		clientId:            config.ClientId,
+		scopes:              config.Scopes,
+		claims:              config.Claims,
+		restrictUnconfirmed: config.RestrictUnconfirmed,
+		tokenHasher:         tokenHasher{pepper: config.RefreshTokenPepper},
+		policy:              config.Policy,
+	}
 }
 
 type TokenSet struct {
@@ -37,50 +119,52 @@ type TokenSet struct {
 	RefreshExpiresAt time.Time
 }
 
-func (svc *SessionService) CreateSession(ctx context.Context, user uuid.UUID) (TokenSet, error) {
-	svc.logger.DebugContext(ctx, "Attempting to create new session", slog.String("user_id", user.String()))
+func (svc *SessionService) CreateSession(ctx context.Context, user entities.User, device entities.Device) (TokenSet, error) {
+	svc.logger.DebugContext(ctx, "Attempting to create new session", slog.String("user_id", user.Id().String()))
 
 	for i := 0; i < svc.maxTokenRetries; i++ {
 		token, err := generateRandomString(32)
 		if err != nil {
-			svc.logger.ErrorContext(ctx, "Generating refresh token failed", slog.String("user_id", user.String()), slog.Any("error", err))
+			svc.logger.ErrorContext(ctx, "Generating refresh token failed", slog.String("user_id", user.Id().String()), slog.Any("error", err))
 			return TokenSet{}, ErrInternal
 		}
 
-		session := entities.NewSession(user, token, svc.sessionDuration)
+		session := entities.NewSession(user.Id(), svc.tokenHasher.hash(token), device, svc.sessionDuration)
 		if err := svc.sessionAppender.AppendSession(ctx, session); err != nil {
 			var duplicationErr *ports.DuplicationError
 			if errors.As(err, &duplicationErr) {
 				if duplicationErr.Field == "token" {
 					svc.logger.WarnContext(
 						ctx, "Session token collision detected, retrying...",
-						slog.String("user_id", user.String()),
-						slog.String("attempted_token_prefix", token[:4]),
+						slog.String("user_id", user.Id().String()),
+						slog.String("attempted_token_prefix", tokenPrefix(token)),
 						slog.Int("retry_count", i+1),
 					)
 					continue
 				}
-				svc.logger.WarnContext(
-					ctx, "User already has an active session (duplication on non-token field)",
-					slog.String("user_id", user.String()),
+				svc.logger.ErrorContext(
+					ctx, "Session creation failed: unhandled duplication field",
+					slog.String("user_id", user.Id().String()),
 					slog.String("duplication_field", duplicationErr.Field),
 					slog.Any("error", err),
 				)
-				return TokenSet{}, ErrUserAlreadyLoggedIn
+				return TokenSet{}, ErrInternal
 			}
 			svc.logger.ErrorContext(
 				ctx, "Failed to append session to repository",
-				slog.String("user_id", user.String()),
+				slog.String("user_id", user.Id().String()),
 				slog.Any("error", err),
 			)
 			return TokenSet{}, ErrInternal
 		}
 
-		accessToken, err := svc.generateAccessToken(session.User())
+		svc.enforcePolicy(ctx, session)
+
+		accessToken, err := svc.generateAccessToken(user, session)
 		if err != nil {
 			svc.logger.ErrorContext(
 				ctx, "Failed to generate access token after session creation",
-				slog.String("user_id", user.String()),
+				slog.String("user_id", user.Id().String()),
 				slog.Any("error", err),
 			)
 			return TokenSet{}, ErrInternal
@@ -88,21 +172,21 @@ func (svc *SessionService) CreateSession(ctx context.Context, user uuid.UUID) (T
 
 		svc.logger.InfoContext(
 			ctx, "Session created successfully",
-			slog.String("user_id", user.String()),
+			slog.String("user_id", user.Id().String()),
 			slog.Time("refresh_expires_at", session.ExpiresAt()),
-			slog.String("refresh_token_prefix", session.Token()[:4]),
+			slog.String("refresh_token_prefix", tokenPrefix(token)),
 		)
 
 		return TokenSet{
 			Access:           accessToken,
-			Refresh:          session.Token(),
+			Refresh:          token,
 			RefreshExpiresAt: session.ExpiresAt(),
 		}, nil
 	}
 
 	svc.logger.ErrorContext(
 		ctx, "Failed to create unique session token after multiple retries",
-		slog.String("user_id", user.String()),
+		slog.String("user_id", user.Id().String()),
 		slog.Int("max_retries", svc.maxTokenRetries),
 	)
 
@@ -112,7 +196,7 @@ func (svc *SessionService) CreateSession(ctx context.Context, user uuid.UUID) (T
 func (svc *SessionService) RefreshAccessToken(ctx context.Context, refreshToken string) (string, error) {
 	svc.logger.DebugContext(
 		ctx, "Attempting to refresh access token",
-		slog.String("refresh_token_prefix", refreshToken[:4]),
+		slog.String("refresh_token_prefix", tokenPrefix(refreshToken)),
 	)
 
 	session, err := svc.findSession(ctx, refreshToken)
@@ -120,7 +204,12 @@ func (svc *SessionService) RefreshAccessToken(ctx context.Context, refreshToken
 		return "", err
 	}
 
-	accessToken, err := svc.generateAccessToken(session.User())
+	user, err := svc.findUser(ctx, session)
+	if err != nil {
+		return "", err
+	}
+
+	accessToken, err := svc.generateAccessToken(user, session)
 	if err != nil {
 		svc.logger.WarnContext(
 			ctx, "Attempted to generate access token",
@@ -134,14 +223,14 @@ func (svc *SessionService) RefreshAccessToken(ctx context.Context, refreshToken
 }
 
 func (svc *SessionService) RefreshSession(ctx context.Context, refreshToken string) (TokenSet, error) {
-	session, err := svc.sessionFinder.Find(ctx, refreshToken)
+	session, err := svc.findSession(ctx, refreshToken)
 	if err != nil {
-		var notFound *ports.NotFoundError
-		if errors.As(err, &notFound) {
-			return TokenSet{}, ErrInvalidToken
-		}
+		return TokenSet{}, err
+	}
 
-		return TokenSet{}, ErrInternal
+	user, err := svc.findUser(ctx, session)
+	if err != nil {
+		return TokenSet{}, err
 	}
 
 	for i := 0; i < svc.maxTokenRetries; i++ {
@@ -149,55 +238,423 @@ func (svc *SessionService) RefreshSession(ctx context.Context, refreshToken stri
 		if err != nil {
 			return TokenSet{}, ErrInternal
 		}
-		session.Refresh(token, svc.sessionDuration)
+		refreshed := session
+		refreshed.Refresh(svc.tokenHasher.hash(token), svc.sessionDuration)
 
-		if err := svc.sessionUpdater.UpdateSession(ctx, session); err != nil {
+		if err := svc.sessionRotator.RotateSession(ctx, refreshed); err != nil {
 			var duplicationErr *ports.DuplicationError
-			if errors.As(err, &duplicationErr) {
-				if duplicationErr.Field == "token" {
-					continue
-				}
+			if errors.As(err, &duplicationErr) && duplicationErr.Field == "token" {
+				continue
+			}
+
+			// The token was rotated by a concurrent refresh or the session
+			// was revoked after it was loaded: the presented token is no
+			// longer current, which is reuse like any other stale token.
+			var notFound *ports.NotFoundError
+			if errors.As(err, &notFound) || errors.As(err, &duplicationErr) {
+				svc.detectTokenReuse(ctx, session.TokenHash())
+				return TokenSet{}, ErrInvalidToken
 			}
 
+			svc.logger.ErrorContext(
+				ctx, "Failed to rotate session",
+				slog.String("session_id", session.Id().String()),
+				slog.Any("error", err),
+			)
 			return TokenSet{}, ErrInternal
 		}
 
-		accessToken, err := svc.generateAccessToken(session.User())
+		accessToken, err := svc.generateAccessToken(user, refreshed)
 		if err != nil {
 			return TokenSet{}, ErrInternal
 		}
 
 		return TokenSet{
 			Access:           accessToken,
-			Refresh:          session.Token(),
-			RefreshExpiresAt: session.ExpiresAt(),
+			Refresh:          token,
+			RefreshExpiresAt: refreshed.ExpiresAt(),
 		}, nil
 	}
 
 	return TokenSet{}, ErrInternal
 }
 
+// Logout revokes the session the refresh token belongs to.
+func (svc *SessionService) Logout(ctx context.Context, refreshToken string) error {
+	session, err := svc.findSession(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+
+	if err := svc.sessionRevoker.RevokeSession(ctx, session.User(), session.Id(), time.Now()); err != nil {
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			return ErrInvalidToken
+		}
+
+		svc.logger.ErrorContext(
+			ctx, "Failed to revoke session on logout",
+			slog.String("session_id", session.Id().String()),
+			slog.Any("error", err),
+		)
+		return ErrInternal
+	}
+
+	svc.logger.InfoContext(
+		ctx, "User logged out",
+		slog.String("user_id", session.User().String()),
+		slog.String("session_id", session.Id().String()),
+	)
+
+	return nil
+}
+
+// ListSessions returns the sessions of the user that are still usable.
+func (svc *SessionService) ListSessions(ctx context.Context, user uuid.UUID) ([]entities.Session, error) {
+	sessions, err := svc.activeSessionFinder.FindActiveByUser(ctx, user, time.Now())
+	if err != nil {
+		svc.logger.ErrorContext(
+			ctx, "Failed to list active sessions",
+			slog.String("user_id", user.String()),
+			slog.Any("error", err),
+		)
+		return nil, ErrInternal
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession ends one session of the user, for example one opened on a
+// lost device.
+func (svc *SessionService) RevokeSession(ctx context.Context, user uuid.UUID, session uuid.UUID) error {
+	if err := svc.sessionRevoker.RevokeSession(ctx, user, session, time.Now()); err != nil {
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			return ErrSessionNotFound
+		}
+
+		svc.logger.ErrorContext(
+			ctx, "Failed to revoke session",
+			slog.String("user_id", user.String()),
+			slog.String("session_id", session.String()),
+			slog.Any("error", err),
+		)
+		return ErrInternal
+	}
+
+	svc.logger.InfoContext(
+		ctx, "Session revoked",
+		slog.String("user_id", user.String()),
+		slog.String("session_id", session.String()),
+	)
+
+	return nil
+}
+
+// RevokeAllSessions logs the user out everywhere.
+func (svc *SessionService) RevokeAllSessions(ctx context.Context, user uuid.UUID) error {
+	revoked, err := svc.sessionRevoker.RevokeUserSessions(ctx, user, time.Now())
+	if err != nil {
+		svc.logger.ErrorContext(
+			ctx, "Failed to revoke all sessions",
+			slog.String("user_id", user.String()),
+			slog.Any("error", err),
+		)
+		return ErrInternal
+	}
+
+	svc.logger.InfoContext(
+		ctx, "All sessions revoked",
+		slog.String("user_id", user.String()),
+		slog.Int("count", revoked),
+	)
+
+	return nil
+}
+
+// RevokeOtherSessions revokes every session of the user except keep, which
+// is usually the session the request was made from.
+func (svc *SessionService) RevokeOtherSessions(ctx context.Context, user uuid.UUID, keep uuid.UUID) error {
+	revoked, err := svc.sessionRevoker.RevokeOtherSessions(ctx, user, keep, time.Now())
+	if err != nil {
+		svc.logger.ErrorContext(
+			ctx, "Failed to revoke other sessions",
+			slog.String("user_id", user.String()),
+			slog.Any("error", err),
+		)
+		return ErrInternal
+	}
+
+	svc.logger.InfoContext(
+		ctx, "Other sessions revoked",
+		slog.String("user_id", user.String()),
+		slog.String("kept_session_id", keep.String()),
+		slog.Int("count", revoked),
+	)
+
+	return nil
+}
+
+// VerifyAccessToken checks the signature, type, validity window, issuer and
+// audience of an access token issued by this service and returns its claims.
+func (svc *SessionService) VerifyAccessToken(ctx context.Context, accessToken string) (AccessToken, error) {
+	token, err := jwt.Parse(
+		accessToken,
+		svc.verificationKey,
+		jwt.WithValidMethods([]string{keys.AlgorithmRS256, keys.AlgorithmES256, keys.AlgorithmEdDSA}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithIssuer(svc.issuer),
+	)
+	if err != nil {
+		svc.logger.DebugContext(ctx, "Rejected access token", slog.Any("error", err))
+		return AccessToken{}, ErrInvalidToken
+	}
+
+	if typ, _ := token.Header["typ"].(string); !strings.EqualFold(typ, accessTokenType) {
+		svc.logger.DebugContext(ctx, "Rejected access token", slog.String("typ", typ))
+		return AccessToken{}, ErrInvalidToken
+	}
+
+	parsed, err := parseAccessToken(token)
+	if err != nil {
+		svc.logger.DebugContext(ctx, "Rejected access token", slog.Any("error", err))
+		return AccessToken{}, ErrInvalidToken
+	}
+
+	// The parser only checks a single audience, while a token is valid here
+	// if it was issued for any of ours.
+	if !slices.ContainsFunc(parsed.Audience, func(aud string) bool { return slices.Contains(svc.audience, aud) }) {
+		svc.logger.DebugContext(ctx, "Rejected access token", slog.Any("audience", parsed.Audience))
+		return AccessToken{}, ErrInvalidToken
+	}
+
+	return parsed, nil
+}
+
+// VerifyActiveAccessToken verifies an access token like VerifyAccessToken and
+// additionally requires the session it was issued for to still be live, so
+// that a logout or revocation takes effect before the token expires. It backs
+// introspection and the endpoints of this service that take access tokens.
+func (svc *SessionService) VerifyActiveAccessToken(ctx context.Context, accessToken string) (AccessToken, error) {
+	parsed, err := svc.VerifyAccessToken(ctx, accessToken)
+	if err != nil {
+		return AccessToken{}, err
+	}
+
+	active, err := svc.activeSessionFinder.FindActiveByUser(ctx, parsed.Subject, time.Now())
+	if err != nil {
+		svc.logger.ErrorContext(
+			ctx, "Failed to list active sessions to verify access token",
+			slog.String("user_id", parsed.Subject.String()),
+			slog.Any("error", err),
+		)
+		return AccessToken{}, ErrInternal
+	}
+
+	if !slices.ContainsFunc(active, func(session entities.Session) bool { return session.Id() == parsed.SessionId }) {
+		svc.logger.DebugContext(
+			ctx, "Rejected access token of an ended session",
+			slog.String("session_id", parsed.SessionId.String()),
+		)
+		return AccessToken{}, ErrInvalidToken
+	}
+
+	return parsed, nil
+}
+
+// verificationKey resolves the public key a token was signed with from its
+// "kid" header. The key's own algorithm must match the token's, so a token
+// cannot pick how it is verified.
+func (svc *SessionService) verificationKey(token *jwt.Token) (any, error) {
+	id, _ := token.Header["kid"].(string)
+
+	key, ok := svc.signingKeys.Lookup(id)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", id)
+	}
+	if token.Method.Alg() != key.Algorithm() {
+		return nil, fmt.Errorf("signing key %q does not sign %s", id, token.Method.Alg())
+	}
+
+	return key.Public(), nil
+}
+
+// JWKS returns the public keys access tokens can be verified with.
+func (svc *SessionService) JWKS() keys.JWKS {
+	return svc.signingKeys.JWKS()
+}
+
+// findUser loads the owner of a session before a token is issued for it.
+// Tokens are never issued to users that have since been deleted.
+func (svc *SessionService) findUser(ctx context.Context, session entities.Session) (entities.User, error) {
+	user, err := svc.userFinder.FindById(ctx, session.User())
+	if err != nil {
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			return entities.User{}, ErrInvalidToken
+		}
+
+		svc.logger.ErrorContext(
+			ctx, "Failed to find session owner",
+			slog.String("session_id", session.Id().String()),
+			slog.Any("error", err),
+		)
+		return entities.User{}, ErrInternal
+	}
+
+	if user.IsDeleted() {
+		svc.logger.InfoContext(
+			ctx, "Refused to issue token to deleted user",
+			slog.String("user_id", user.Id().String()),
+			slog.String("session_id", session.Id().String()),
+		)
+		return entities.User{}, ErrInvalidToken
+	}
+
+	return user, nil
+}
+
 func (svc *SessionService) findSession(ctx context.Context, token string) (entities.Session, error) {
-	session, err := svc.sessionFinder.Find(ctx, token)
+	tokenHash := svc.tokenHasher.hash(token)
+
+	session, err := svc.sessionFinder.Find(ctx, tokenHash)
 	if err != nil {
 		var notFound *ports.NotFoundError
 		if errors.As(err, &notFound) {
+			svc.detectTokenReuse(ctx, tokenHash)
 			return entities.Session{}, ErrInvalidToken
 		}
 
+		svc.logger.ErrorContext(ctx, "Failed to find session", slog.Any("error", err))
 		return entities.Session{}, ErrInternal
 	}
 
+	if session.IsRevoked() {
+		svc.logger.InfoContext(
+			ctx, "Rejected revoked session",
+			slog.String("user_id", session.User().String()),
+			slog.String("session_id", session.Id().String()),
+		)
+		return entities.Session{}, ErrInvalidToken
+	}
+
+	if session.IsExpired(time.Now()) {
+		svc.logger.InfoContext(
+			ctx, "Rejected expired session",
+			slog.String("user_id", session.User().String()),
+			slog.Time("expired_at", session.ExpiresAt()),
+		)
+		return entities.Session{}, ErrInvalidToken
+	}
+
 	return session, nil
 }
 
-func (svc *SessionService) generateAccessToken(user uuid.UUID) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"exp": time.Now().Add(svc.accessTokenDuration),
-		"sub": user.String(),
+// enforcePolicy revokes the sessions the policy does not allow to coexist
+// with the freshly created one. It runs after the new session is stored so
+// that concurrent logins cannot both slip under the limit. Failures are
+// logged rather than returned: the new session is valid either way.
+func (svc *SessionService) enforcePolicy(ctx context.Context, created entities.Session) {
+	if svc.policy.Mode == SessionPolicyUnlimited || svc.policy.Mode == "" {
+		return
+	}
+
+	active, err := svc.activeSessionFinder.FindActiveByUser(ctx, created.User(), time.Now())
+	if err != nil {
+		svc.logger.ErrorContext(
+			ctx, "Failed to list active sessions to enforce session policy",
+			slog.String("user_id", created.User().String()),
+			slog.Any("error", err),
+		)
+		return
+	}
+
+	active = slices.DeleteFunc(active, func(session entities.Session) bool {
+		return session.Id() == created.Id()
 	})
 
-	tokenStr, err := token.SignedString(svc.tokenSecret)
+	for _, session := range svc.policy.evictions(active) {
+		if err := svc.sessionRevoker.RevokeSession(ctx, session.User(), session.Id(), time.Now()); err != nil {
+			var notFound *ports.NotFoundError
+			if errors.As(err, &notFound) {
+				continue
+			}
+
+			svc.logger.ErrorContext(
+				ctx, "Failed to revoke session evicted by session policy",
+				slog.String("session_id", session.Id().String()),
+				slog.Any("error", err),
+			)
+			continue
+		}
+
+		svc.logger.InfoContext(
+			ctx, "Session evicted by session policy",
+			slog.String("user_id", session.User().String()),
+			slog.String("session_id", session.Id().String()),
+			slog.String("policy", string(svc.policy.Mode)),
+		)
+	}
+}
+
+// detectTokenReuse checks whether an unknown refresh token is one that was
+// already rotated away. Such a token can only be presented by someone holding
+// a stale copy of it, so the whole session it belongs to is revoked: whichever
+// of the legitimate client and the attacker holds the current token loses it.
+func (svc *SessionService) detectTokenReuse(ctx context.Context, tokenHash string) {
+	session, err := svc.rotatedTokenFinder.FindByRotatedToken(ctx, tokenHash)
+	if err != nil {
+		var notFound *ports.NotFoundError
+		if !errors.As(err, &notFound) {
+			svc.logger.ErrorContext(ctx, "Failed to look up rotated token", slog.Any("error", err))
+		}
+		return
+	}
+
+	svc.logger.WarnContext(
+		ctx, "Rotated refresh token reused",
+		slog.String("user_id", session.User().String()),
+		slog.String("session_id", session.Id().String()),
+		slog.Bool("already_revoked", session.IsRevoked()),
+	)
+
+	if session.IsRevoked() {
+		return
+	}
+
+	if err := svc.sessionRevoker.RevokeSession(ctx, session.User(), session.Id(), time.Now()); err != nil {
+		var notFound *ports.NotFoundError
+		if errors.As(err, &notFound) {
+			return
+		}
+
+		svc.logger.ErrorContext(
+			ctx, "Failed to revoke session after token reuse",
+			slog.String("session_id", session.Id().String()),
+			slog.Any("error", err),
+		)
+		return
+	}
+
+	event := entities.NewSecurityEvent(entities.SecurityEventRefreshTokenReuse, session.User(), session.Id())
+	if err := svc.securityEvents.PublishSecurityEvent(ctx, event); err != nil {
+		svc.logger.ErrorContext(
+			ctx, "Failed to publish security event",
+			slog.String("kind", string(event.Kind)),
+			slog.Any("error", err),
+		)
+	}
+}
+
+func (svc *SessionService) generateAccessToken(user entities.User, session entities.Session) (string, error) {
+	key := svc.signingKeys.Active()
+
+	token := jwt.NewWithClaims(key.SigningMethod(), svc.accessTokenClaims(user, session))
+	token.Header["typ"] = accessTokenType
+	token.Header["kid"] = key.Id()
+
+	tokenStr, err := token.SignedString(key.Private())
 	if err != nil {
 		return "", ErrInternal
 	}
@@ -0,0 +1,234 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+func TestSessionServiceRefreshSessionRotatesToken(t *testing.T) {
+	f := newFixture(t, SessionPolicy{Mode: SessionPolicyUnlimited})
+	user := f.addUser(t, "alice", "correct horse")
+	first := f.login(t, user)
+
+	second, err := f.sessions.RefreshSession(context.Background(), first.Refresh)
+	if err != nil {
+		t.Fatalf("RefreshSession() error = %v", err)
+	}
+	if second.Refresh == first.Refresh || second.Access == "" {
+		t.Fatalf("RefreshSession() = %+v, want a new refresh and an access token", second)
+	}
+
+	third, err := f.sessions.RefreshSession(context.Background(), second.Refresh)
+	if err != nil {
+		t.Fatalf("RefreshSession() with rotated token error = %v", err)
+	}
+
+	sessions := f.activeSessions(t, user)
+	if len(sessions) != 1 {
+		t.Fatalf("active sessions = %d, want rotation to keep 1", len(sessions))
+	}
+	if _, err := f.sessions.RefreshAccessToken(context.Background(), third.Refresh); err != nil {
+		t.Errorf("RefreshAccessToken() with current token error = %v", err)
+	}
+	if events := f.events.published(); len(events) != 0 {
+		t.Errorf("published %v, want no security events", events)
+	}
+}
+
+func TestSessionServiceRejectsTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		// login opens a session of a user and returns the refresh token to
+		// present.
+		login func(t *testing.T, f *fixture, user entities.User) string
+		// wantRevoked is whether presenting the token revokes the session.
+		wantRevoked bool
+		deleted     bool
+	}{
+		{
+			name: "unknown",
+			login: func(t *testing.T, f *fixture, user entities.User) string {
+				f.login(t, user)
+				return "not a refresh token"
+			},
+		},
+		{
+			name: "logged out",
+			login: func(t *testing.T, f *fixture, user entities.User) string {
+				tokens := f.login(t, user)
+				if err := f.sessions.Logout(context.Background(), tokens.Refresh); err != nil {
+					t.Fatalf("Logout() error = %v", err)
+				}
+				return tokens.Refresh
+			},
+			wantRevoked: true,
+		},
+		{
+			name: "of a deleted user",
+			login: func(t *testing.T, f *fixture, user entities.User) string {
+				return f.login(t, user).Refresh
+			},
+			deleted: true,
+		},
+		{
+			name: "rotated away",
+			login: func(t *testing.T, f *fixture, user entities.User) string {
+				tokens := f.login(t, user)
+				if _, err := f.sessions.RefreshSession(context.Background(), tokens.Refresh); err != nil {
+					t.Fatalf("RefreshSession() error = %v", err)
+				}
+				return tokens.Refresh
+			},
+			wantRevoked: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, SessionPolicy{Mode: SessionPolicyUnlimited})
+			hash, err := f.hasher.Hash("correct horse")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			user := loadUser("alice", hash, true, tt.deleted)
+			f.storeUser(t, user)
+			token := tt.login(t, f, user)
+
+			if _, err := f.sessions.RefreshSession(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("RefreshSession() error = %v, want ErrInvalidToken", err)
+			}
+			if _, err := f.sessions.RefreshAccessToken(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("RefreshAccessToken() error = %v, want ErrInvalidToken", err)
+			}
+			if revoked := len(f.activeSessions(t, user)) == 0; revoked != tt.wantRevoked {
+				t.Errorf("session revoked = %v, want %v", revoked, tt.wantRevoked)
+			}
+		})
+	}
+}
+
+// A rotated token presented again is held by someone with a stale copy, so
+// the whole session is revoked and the current token stops working too.
+func TestSessionServiceDetectsTokenReuse(t *testing.T) {
+	f := newFixture(t, SessionPolicy{Mode: SessionPolicyUnlimited})
+	user := f.addUser(t, "alice", "correct horse")
+	other := f.login(t, user)
+	stolen := f.login(t, user)
+
+	current, err := f.sessions.RefreshSession(context.Background(), stolen.Refresh)
+	if err != nil {
+		t.Fatalf("RefreshSession() error = %v", err)
+	}
+
+	for range 2 {
+		if _, err := f.sessions.RefreshSession(context.Background(), stolen.Refresh); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("RefreshSession() with reused token error = %v, want ErrInvalidToken", err)
+		}
+	}
+
+	if _, err := f.sessions.RefreshSession(context.Background(), current.Refresh); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("RefreshSession() with current token after reuse error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := f.sessions.RefreshSession(context.Background(), other.Refresh); err != nil {
+		t.Errorf("RefreshSession() of another session error = %v", err)
+	}
+
+	events := f.events.published()
+	if len(events) != 1 {
+		t.Fatalf("published %d security events, want 1 for the first reuse", len(events))
+	}
+	if events[0].Kind != entities.SecurityEventRefreshTokenReuse || events[0].User != user.Id() {
+		t.Errorf("published %+v, want refresh token reuse of %s", events[0], user.Id())
+	}
+}
+
+// Refreshes racing with the same token compare and swap it: one wins, and
+// every other one presents a token that is no longer current.
+func TestSessionServiceRefreshSessionRace(t *testing.T) {
+	f := newFixture(t, SessionPolicy{Mode: SessionPolicyUnlimited})
+	user := f.addUser(t, "alice", "correct horse")
+	tokens := f.login(t, user)
+
+	const concurrency = 16
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		won      int
+		rejected int
+	)
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := f.sessions.RefreshSession(context.Background(), tokens.Refresh)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				won++
+			case errors.Is(err, ErrInvalidToken):
+				rejected++
+			default:
+				t.Errorf("RefreshSession() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if won != 1 || rejected != concurrency-1 {
+		t.Errorf("refreshes won, rejected = %d, %d, want 1, %d", won, rejected, concurrency-1)
+	}
+	if sessions := f.activeSessions(t, user); len(sessions) != 0 {
+		t.Errorf("active sessions = %d, want the raced session revoked", len(sessions))
+	}
+}
+
+func TestSessionServicePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy SessionPolicy
+		// wantKept are the logins, oldest first, whose sessions survive
+		// four logins.
+		wantKept []int
+	}{
+		{"unlimited", SessionPolicy{Mode: SessionPolicyUnlimited}, []int{0, 1, 2, 3}},
+		{"unset", SessionPolicy{Mode: ""}, []int{0, 1, 2, 3}},
+		{"limited to 2", SessionPolicy{Mode: SessionPolicyLimited, MaxSessions: 2}, []int{2, 3}},
+		{"limited to 0", SessionPolicy{Mode: SessionPolicyLimited, MaxSessions: 0}, []int{3}},
+		{"limited to 10", SessionPolicy{Mode: SessionPolicyLimited, MaxSessions: 10}, []int{0, 1, 2, 3}},
+		{"single", SessionPolicy{Mode: SessionPolicySingle}, []int{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, tt.policy)
+			user := f.addUser(t, "alice", "correct horse")
+			bystander := f.addUser(t, "bob", "battery staple")
+			f.login(t, bystander)
+
+			var logins []TokenSet
+			for range 4 {
+				logins = append(logins, f.login(t, user))
+			}
+
+			var kept []int
+			for i, tokens := range logins {
+				if _, err := f.sessions.RefreshAccessToken(context.Background(), tokens.Refresh); err == nil {
+					kept = append(kept, i)
+				}
+			}
+			if !slices.Equal(kept, tt.wantKept) {
+				t.Errorf("kept logins = %v, want %v", kept, tt.wantKept)
+			}
+			if sessions := f.activeSessions(t, bystander); len(sessions) != 1 {
+				t.Errorf("active sessions of another user = %d, want 1", len(sessions))
+			}
+		})
+	}
+}
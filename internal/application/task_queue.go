@@ -0,0 +1,125 @@
+package application
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// TaskQueue runs work after the request that queued it has been answered.
+// Services queue whatever only happens for registered users, such as mailing
+// them, so that neither the time a response takes nor its status reveals
+// whether an address is registered. Tasks queued while the queue is full are
+// dropped and logged.
+type TaskQueue struct {
+	logger *slog.Logger
+
+	tasks   chan task
+	workers int
+
+	mu      sync.Mutex
+	pending map[string]struct{}
+}
+
+type task struct {
+	ctx  context.Context
+	name string
+	key  string
+	run  func(ctx context.Context)
+}
+
+func NewTaskQueue(logger *slog.Logger, size int, workers int) *TaskQueue {
+	return &TaskQueue{
+		logger:  logger,
+		tasks:   make(chan task, size),
+		workers: workers,
+		pending: make(map[string]struct{}),
+	}
+}
+
+// Enqueue queues run without blocking. run is passed a context carrying the
+// values of ctx but not its cancellation, as ctx usually ends with the
+// request.
+func (q *TaskQueue) Enqueue(ctx context.Context, name string, run func(ctx context.Context)) {
+	q.enqueue(ctx, task{ctx: context.WithoutCancel(ctx), name: name, run: run})
+}
+
+// EnqueueFor is Enqueue for work about key, such as an email address. While a
+// task for the key is queued or running, further ones are dropped, so a flood
+// of requests about one address takes up a single place in the queue.
+func (q *TaskQueue) EnqueueFor(ctx context.Context, name string, key string, run func(ctx context.Context)) {
+	q.mu.Lock()
+	_, pending := q.pending[key]
+	if !pending {
+		q.pending[key] = struct{}{}
+	}
+	q.mu.Unlock()
+
+	if pending {
+		q.logger.DebugContext(ctx, "Task already queued, task dropped", slog.String("task", name))
+		return
+	}
+
+	if !q.enqueue(ctx, task{ctx: context.WithoutCancel(ctx), name: name, key: key, run: run}) {
+		q.release(key)
+	}
+}
+
+func (q *TaskQueue) enqueue(ctx context.Context, t task) bool {
+	select {
+	case q.tasks <- t:
+		return true
+	default:
+		q.logger.WarnContext(ctx, "Task queue full, task dropped", slog.String("task", t.name))
+		return false
+	}
+}
+
+func (q *TaskQueue) release(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.pending, key)
+}
+
+// Run executes queued tasks until ctx is cancelled, then finishes the tasks
+// still queued and returns.
+func (q *TaskQueue) Run(ctx context.Context) {
+	q.logger.InfoContext(ctx, "Task queue started", slog.Int("workers", q.workers))
+
+	var wg sync.WaitGroup
+	wg.Add(q.workers)
+	for range q.workers {
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	wg.Wait()
+
+	q.logger.InfoContext(ctx, "Task queue stopped")
+}
+
+func (q *TaskQueue) work(ctx context.Context) {
+	for {
+		select {
+		case t := <-q.tasks:
+			q.run(t)
+		case <-ctx.Done():
+			for {
+				select {
+				case t := <-q.tasks:
+					q.run(t)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (q *TaskQueue) run(t task) {
+	t.run(t.ctx)
+	if t.key != "" {
+		q.release(t.key)
+	}
+}
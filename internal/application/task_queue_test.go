@@ -0,0 +1,70 @@
+package application
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+func TestTaskQueueEnqueueFor(t *testing.T) {
+	queue := NewTaskQueue(slog.New(slog.NewTextHandler(io.Discard, nil)), 8, 1)
+
+	var (
+		mu  sync.Mutex
+		ran []string
+	)
+	record := func(name string) func(context.Context) {
+		return func(context.Context) {
+			mu.Lock()
+			defer mu.Unlock()
+			ran = append(ran, name)
+		}
+	}
+
+	ctx := context.Background()
+	queue.EnqueueFor(ctx, "first", "alice@example.com", record("first"))
+	queue.EnqueueFor(ctx, "duplicate", "alice@example.com", record("duplicate"))
+	queue.EnqueueFor(ctx, "other", "bob@example.com", record("other"))
+	queue.Enqueue(ctx, "unkeyed", record("unkeyed"))
+
+	// Run finishes the queued tasks before returning on a cancelled context.
+	done, cancel := context.WithCancel(ctx)
+	cancel()
+	queue.Run(done)
+
+	queue.EnqueueFor(ctx, "after", "alice@example.com", record("after"))
+	queue.Run(done)
+
+	want := []string{"first", "other", "unkeyed", "after"}
+	if len(ran) != len(want) {
+		t.Fatalf("ran %v, want %v", ran, want)
+	}
+	for i := range want {
+		if ran[i] != want[i] {
+			t.Fatalf("ran %v, want %v", ran, want)
+		}
+	}
+}
+
+func TestTaskQueueEnqueueForFullQueue(t *testing.T) {
+	queue := NewTaskQueue(slog.New(slog.NewTextHandler(io.Discard, nil)), 1, 1)
+	ctx := context.Background()
+	noop := func(context.Context) {}
+
+	queue.Enqueue(ctx, "filler", noop)
+	queue.EnqueueFor(ctx, "dropped", "alice@example.com", noop)
+
+	done, cancel := context.WithCancel(ctx)
+	cancel()
+	queue.Run(done)
+
+	// The dropped task must not keep later ones for its key out.
+	ran := false
+	queue.EnqueueFor(ctx, "retry", "alice@example.com", func(context.Context) { ran = true })
+	queue.Run(done)
+	if !ran {
+		t.Fatal("task for the key of a dropped task did not run")
+	}
+}
@@ -0,0 +1,38 @@
+package application
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// tokenHasher derives the value refresh and action tokens are stored and
+// looked up by. The hash is keyed with a server-side pepper, so read access to
+// the database alone is not enough to turn a stored value back into a usable
+// token.
+type tokenHasher struct {
+	pepper []byte
+}
+
+// newActionTokenHasher keys the hashes of action tokens for purpose with
+// HMAC(pepper, purpose), so that the pepper shared with refresh tokens never
+// keys two kinds of token alike.
+func newActionTokenHasher(pepper []byte, purpose entities.ActionPurpose) tokenHasher {
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write([]byte(purpose))
+	return tokenHasher{pepper: mac.Sum(nil)}
+}
+
+func (h tokenHasher) hash(token string) string {
+	mac := hmac.New(sha256.New, h.pepper)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HashRefreshToken hashes token the way SessionService stores it, for
+// migrating tokens that were stored before hashing was introduced.
+func HashRefreshToken(pepper []byte, token string) string {
+	return tokenHasher{pepper: pepper}.hash(token)
+}
@@ -12,3 +12,10 @@ func generateRandomString(length int) (string, error) {
 	}
 	return base64.RawURLEncoding.EncodeToString(b), nil
 }
+
+func tokenPrefix(token string) string {
+	if len(token) < 4 {
+		return token
+	}
+	return token[:4]
+}
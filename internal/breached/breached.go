@@ -0,0 +1,64 @@
+// Package breached looks passwords up in a local copy of a breached-password
+// corpus such as Have I Been Pwned's Pwned Passwords, so no password or hash
+// prefix ever leaves the host.
+//
+// Two layouts of the SHA-1 corpus are supported: a single file of
+// "<SHA-1>:<count>" lines sorted by hash, which is binary searched on disk,
+// and a directory of k-anonymity range files named after the first five hex
+// digits of the hash, holding "<remaining 35 digits>:<count>" lines.
+package breached
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+)
+
+var ErrMalformedCorpus = errors.New("malformed breach corpus")
+
+const (
+	hashLength   = 2 * sha1.Size
+	prefixLength = 5
+)
+
+// Open opens the corpus at path, a sorted file or a range directory.
+func Open(path string) (ports.BreachedPasswordChecker, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breach corpus: %w", err)
+	}
+
+	if info.IsDir() {
+		return OpenRangeDir(path)
+	}
+	return OpenSortedFile(path)
+}
+
+// hash returns the upper-case hex SHA-1 of password, the form the corpus is
+// keyed by.
+func hash(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// parseLine splits a "<hash>:<count>" line. Lines without a count, as in
+// some trimmed corpora, count once.
+func parseLine(line string) (string, int, error) {
+	line = strings.TrimRight(line, "\r")
+	digest, count, ok := strings.Cut(line, ":")
+	if !ok {
+		return strings.ToUpper(digest), 1, nil
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n < 0 {
+		return "", 0, fmt.Errorf("%w: invalid count in %q", ErrMalformedCorpus, line)
+	}
+	return strings.ToUpper(digest), n, nil
+}
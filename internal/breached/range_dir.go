@@ -0,0 +1,71 @@
+package breached
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+)
+
+// RangeDir is a corpus split into one file per five-digit hash prefix, as
+// written by the Pwned Passwords downloader or served by its range API. A
+// file is named after its prefix, with or without a .txt extension.
+type RangeDir struct {
+	dir string
+}
+
+var _ ports.BreachedPasswordChecker = (*RangeDir)(nil)
+
+func OpenRangeDir(dir string) (*RangeDir, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "?????*"))
+	if err != nil || len(matches) == 0 {
+		return nil, fmt.Errorf("%w: %s holds no range files", ErrMalformedCorpus, dir)
+	}
+
+	return &RangeDir{dir: dir}, nil
+}
+
+// BreachCount implements ports.BreachedPasswordChecker. A missing range file
+// counts as an empty range, so a partial corpus still works.
+func (c *RangeDir) BreachCount(_ context.Context, password string) (int, error) {
+	digest := hash(password)
+	prefix, suffix := digest[:prefixLength], digest[prefixLength:]
+
+	file, err := c.open(prefix)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read breach corpus: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		candidate, count, err := parseLine(scanner.Text())
+		if err != nil {
+			return 0, err
+		}
+		if candidate == suffix {
+			return count, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read breach corpus: %w", err)
+	}
+
+	return 0, nil
+}
+
+func (c *RangeDir) open(prefix string) (*os.File, error) {
+	file, err := os.Open(filepath.Join(c.dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return os.Open(filepath.Join(c.dir, prefix))
+	}
+	return file, err
+}
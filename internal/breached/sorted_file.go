@@ -0,0 +1,118 @@
+package breached
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+)
+
+// maxLineLength bounds a "<hash>:<count>\r\n" line with a generous count.
+const maxLineLength = 64
+
+// SortedFile is a corpus file of "<SHA-1>:<count>" lines sorted by hash. It
+// is searched in place, so even the full corpus of tens of gigabytes needs
+// no memory and takes a few dozen reads per lookup.
+type SortedFile struct {
+	file *os.File
+	size int64
+}
+
+var _ ports.BreachedPasswordChecker = (*SortedFile)(nil)
+
+func OpenSortedFile(path string) (*SortedFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breach corpus: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to open breach corpus: %w", err)
+	}
+
+	c := &SortedFile{file: file, size: info.Size()}
+
+	// Check the first line, so a wrong file is caught at startup rather
+	// than on the first lookup.
+	_, line, err := c.lineAt(0, make([]byte, 2*maxLineLength))
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if digest, _, err := parseLine(string(line)); err != nil || len(digest) != hashLength {
+		_ = file.Close()
+		return nil, fmt.Errorf("%w: %s does not start with a SHA-1 hash", ErrMalformedCorpus, path)
+	}
+
+	return c, nil
+}
+
+func (c *SortedFile) Close() error {
+	return c.file.Close()
+}
+
+func (c *SortedFile) BreachCount(_ context.Context, password string) (int, error) {
+	target := []byte(hash(password))
+	buf := make([]byte, 2*maxLineLength)
+
+	// The candidates are the lines starting in [lo, hi).
+	lo, hi := int64(0), c.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, line, err := c.lineAt(mid, buf)
+		if err != nil {
+			return 0, err
+		}
+		if start >= hi || len(line) < hashLength {
+			hi = mid
+			continue
+		}
+
+		switch cmp := bytes.Compare(bytes.ToUpper(line[:hashLength]), target); {
+		case cmp == 0:
+			_, count, err := parseLine(string(line))
+			return count, err
+		case cmp < 0:
+			lo = start + int64(len(line)) + 1
+		default:
+			hi = mid
+		}
+	}
+
+	return 0, nil
+}
+
+// lineAt returns the first line starting at or after offset, without its
+// line break, and where it starts. Past the last line it returns the file
+// size and no line.
+func (c *SortedFile) lineAt(offset int64, buf []byte) (int64, []byte, error) {
+	readFrom := max(offset-1, 0)
+	n, err := c.file.ReadAt(buf, readFrom)
+	if err != nil && err != io.EOF {
+		return 0, nil, fmt.Errorf("failed to read breach corpus: %w", err)
+	}
+	chunk := buf[:n]
+
+	start := readFrom
+	if offset > 0 {
+		i := bytes.IndexByte(chunk, '\n')
+		if i < 0 {
+			return c.size, nil, nil
+		}
+		start += int64(i) + 1
+		chunk = chunk[i+1:]
+	}
+
+	if i := bytes.IndexByte(chunk, '\n'); i >= 0 {
+		chunk = chunk[:i]
+	} else if start+int64(len(chunk)) < c.size {
+		return 0, nil, fmt.Errorf("%w: line at offset %d is too long", ErrMalformedCorpus, start)
+	}
+	return start, bytes.TrimRight(chunk, "\r"), nil
+}
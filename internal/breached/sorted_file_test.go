@@ -0,0 +1,187 @@
+package breached
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fixtureEntry is a password of the fixture corpus and its breach count.
+type fixtureEntry struct {
+	password string
+	hash     string
+	count    int
+}
+
+// writeCorpus writes a sorted corpus of n passwords with the given line break
+// and returns its path along with the entries in file order.
+func writeCorpus(t *testing.T, n int, lineBreak string) (string, []fixtureEntry) {
+	t.Helper()
+
+	entries := make([]fixtureEntry, n)
+	for i := range entries {
+		password := fmt.Sprintf("password%d", i)
+		entries[i] = fixtureEntry{password: password, hash: hash(password), count: 1000 + i}
+	}
+	slices.SortFunc(entries, func(a, b fixtureEntry) int { return strings.Compare(a.hash, b.hash) })
+
+	var b strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%s:%d%s", e.hash, e.count, lineBreak)
+	}
+
+	path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatalf("failed to write corpus: %v", err)
+	}
+	return path, entries
+}
+
+// missingPassword returns a password outside the fixture corpus whose hash
+// accept takes.
+func missingPassword(t *testing.T, accept func(hash string) bool) string {
+	t.Helper()
+
+	for i := range 100_000 {
+		password := fmt.Sprintf("missing%d", i)
+		if accept(hash(password)) {
+			return password
+		}
+	}
+	t.Fatal("no missing password found")
+	return ""
+}
+
+func TestSortedFileBreachCount(t *testing.T) {
+	for name, lineBreak := range map[string]string{"CRLF": "\r\n", "LF": "\n"} {
+		t.Run(name, func(t *testing.T) {
+			path, entries := writeCorpus(t, 64, lineBreak)
+			corpus, err := OpenSortedFile(path)
+			if err != nil {
+				t.Fatalf("OpenSortedFile() error = %v", err)
+			}
+			t.Cleanup(func() { _ = corpus.Close() })
+
+			first, last := entries[0], entries[len(entries)-1]
+			middle := entries[len(entries)/2]
+			for _, tt := range []struct {
+				name  string
+				entry fixtureEntry
+			}{{"first", first}, {"middle", middle}, {"last", last}} {
+				got, err := corpus.BreachCount(context.Background(), tt.entry.password)
+				if err != nil || got != tt.entry.count {
+					t.Errorf("BreachCount() of %s line = %d, %v, want %d", tt.name, got, err, tt.entry.count)
+				}
+			}
+
+			for i, e := range entries {
+				got, err := corpus.BreachCount(context.Background(), e.password)
+				if err != nil || got != e.count {
+					t.Errorf("BreachCount() of line %d = %d, %v, want %d", i, got, err, e.count)
+				}
+			}
+
+			missing := map[string]string{
+				"before the first line": missingPassword(t, func(h string) bool { return h < first.hash }),
+				"after the last line":   missingPassword(t, func(h string) bool { return h > last.hash }),
+				"between lines": missingPassword(t, func(h string) bool {
+					return h > first.hash && h < last.hash
+				}),
+			}
+			for name, password := range missing {
+				got, err := corpus.BreachCount(context.Background(), password)
+				if err != nil || got != 0 {
+					t.Errorf("BreachCount() of password %s = %d, %v, want 0", name, got, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSortedFileSingleLine(t *testing.T) {
+	path, entries := writeCorpus(t, 1, "\r\n")
+	corpus, err := OpenSortedFile(path)
+	if err != nil {
+		t.Fatalf("OpenSortedFile() error = %v", err)
+	}
+	t.Cleanup(func() { _ = corpus.Close() })
+
+	got, err := corpus.BreachCount(context.Background(), entries[0].password)
+	if err != nil || got != entries[0].count {
+		t.Errorf("BreachCount() = %d, %v, want %d", got, err, entries[0].count)
+	}
+	got, err = corpus.BreachCount(context.Background(), "not in the corpus")
+	if err != nil || got != 0 {
+		t.Errorf("BreachCount() of missing password = %d, %v, want 0", got, err)
+	}
+}
+
+func TestSortedFileLineAt(t *testing.T) {
+	const content = "AAAA:1\r\nBBBB:22\r\nCCCC:333"
+	path := filepath.Join(t.TempDir(), "corpus.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write corpus: %v", err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open corpus: %v", err)
+	}
+	t.Cleanup(func() { _ = file.Close() })
+	corpus := &SortedFile{file: file, size: int64(len(content))}
+
+	tests := []struct {
+		offset    int64
+		wantStart int64
+		wantLine  string
+	}{
+		{0, 0, "AAAA:1"},
+		{1, 8, "BBBB:22"},    // inside the first line
+		{6, 8, "BBBB:22"},    // at the first line's \r
+		{7, 8, "BBBB:22"},    // at the first line's \n
+		{8, 8, "BBBB:22"},    // at the start of the second line
+		{9, 17, "CCCC:333"},  // inside the second line
+		{17, 17, "CCCC:333"}, // at the start of the last line, which has no line break
+		{18, int64(len(content)), ""},
+		{int64(len(content)), int64(len(content)), ""},
+	}
+
+	for _, tt := range tests {
+		start, line, err := corpus.lineAt(tt.offset, make([]byte, 2*maxLineLength))
+		if err != nil {
+			t.Errorf("lineAt(%d) error = %v", tt.offset, err)
+			continue
+		}
+		if start != tt.wantStart || string(line) != tt.wantLine {
+			t.Errorf("lineAt(%d) = %d, %q, want %d, %q", tt.offset, start, line, tt.wantStart, tt.wantLine)
+		}
+	}
+}
+
+func TestOpenSortedFileRejectsOtherFiles(t *testing.T) {
+	for name, content := range map[string]string{
+		"not a hash":      "password:12\n",
+		"short hash":      "5BAA61E4:3\n",
+		"line too long":   strings.Repeat("A", 3*maxLineLength),
+		"malformed count": hash("password") + ":many\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "corpus.txt")
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatalf("failed to write corpus: %v", err)
+			}
+
+			corpus, err := OpenSortedFile(path)
+			if !errors.Is(err, ErrMalformedCorpus) {
+				if corpus != nil {
+					_ = corpus.Close()
+				}
+				t.Errorf("OpenSortedFile() error = %v, want ErrMalformedCorpus", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,515 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type Config struct {
+	LogLevel slog.Level
+
+	HTTP      HTTP
+	Storage   Storage
+	Postgres  Postgres
+	Sessions  Sessions
+	Passwords Passwords
+
+	Introspection     Introspection
+	EmailConfirmation EmailConfirmation
+	PasswordReset     PasswordReset
+	Mail              Mail
+	Locales           Locales
+}
+
+type HTTP struct {
+	Address         string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	ShutdownTimeout time.Duration
+}
+
+const (
+	StorageDriverPostgres = "postgres"
+	StorageDriverMemory   = "memory"
+)
+
+type Storage struct {
+	Driver string
+}
+
+type Postgres struct {
+	URL string
+}
+
+// Introspection configures the RFC 7662 token introspection endpoint, which
+// is disabled unless at least one client is configured.
+type Introspection struct {
+	// Clients maps client ids to the secrets they authenticate with.
+	Clients map[string]string
+}
+
+type EmailConfirmation struct {
+	TokenDuration  time.Duration
+	ResendCooldown time.Duration
+	// LinkTemplate is the confirmation URL mailed to users, with "{token}"
+	// standing in for the token.
+	LinkTemplate string
+	Policy       string
+}
+
+type PasswordReset struct {
+	TokenDuration   time.Duration
+	RequestCooldown time.Duration
+	// LinkTemplate is the reset URL mailed to users, with "{token}" standing
+	// in for the token.
+	LinkTemplate string
+}
+
+const (
+	MailDriverLog  = "log"
+	MailDriverSMTP = "smtp"
+	MailDriverFile = "file"
+)
+
+// Locales configures the languages messages to users are translated into.
+type Locales struct {
+	Default string
+	// CatalogDir overrides the built-in message catalogs when set.
+	CatalogDir string
+}
+
+type Mail struct {
+	Driver string
+	From   string
+	// TemplateDir overrides the built-in templates when set.
+	TemplateDir   string
+	DefaultLocale string
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	// SMTPSecurity is "starttls", "tls" for implicit TLS or "none".
+	SMTPSecurity string
+
+	// FileDir is where the file driver drops .eml files.
+	FileDir string
+
+	// QueueSize is how many mails may wait to be sent before further ones
+	// are dropped, Workers how many are sent at once. Both apply to the
+	// queue of each mailing endpoint separately.
+	QueueSize int
+	Workers   int
+}
+
+// Passwords configures how new password hashes are made. Hashes with weaker
+// parameters or another algorithm are replaced on the next login.
+type Passwords struct {
+	HashAlgorithm string
+	// Argon2Memory is in KiB.
+	Argon2Memory      int
+	Argon2Iterations  int
+	Argon2Parallelism int
+	BcryptCost        int
+
+	// BreachCorpus is a sorted SHA-1 file or range directory of breached
+	// passwords, see the breached package; empty disables the check.
+	BreachCorpus   string
+	BreachPolicy   string
+	BreachMinCount int
+
+	// MinLength is in characters, MaxLength in bytes.
+	MinLength        int
+	MaxLength        int
+	RequiredClasses  []string
+	MinClasses       int
+	BannedSubstrings []string
+	BanUserInputs    bool
+	MinStrength      int
+}
+
+type Sessions struct {
+	MaxTokenRetries     int
+	Duration            time.Duration
+	AccessTokenDuration time.Duration
+	RefreshTokenPepper  string
+	Issuer              string
+	Audience            []string
+	ClientId            string
+	Scopes              []string
+
+	// SigningKeyFile is a PEM private key access tokens are signed with and
+	// SigningKeyDir a directory of them, see keys.Dir. One of them is
+	// required unless EphemeralKeys allows generating SigningAlgorithm keys
+	// on every start, which only suits a single development instance.
+	SigningKeyFile   string
+	SigningKeyDir    string
+	SigningAlgorithm string
+	EphemeralKeys    bool
+	// KeyRotationInterval is how old the signing key may get before a new one
+	// is generated; zero disables scheduled rotation.
+	KeyRotationInterval time.Duration
+	KeyReloadInterval   time.Duration
+	// KeyPublishDelay is how long a new key is published before it signs
+	// tokens, so verifiers caching the published keys pick it up first.
+	KeyPublishDelay time.Duration
+
+	ReaperInterval  time.Duration
+	ReaperBatchSize int
+
+	Policy     string
+	MaxPerUser int
+}
+
+// Load reads the configuration from the environment, falling back to defaults
+// for everything except secrets and connection strings.
+func Load() (Config, error) {
+	var errs []error
+
+	defaultLocale := envString("DEFAULT_LOCALE", "en")
+
+	cfg := Config{
+		LogLevel: envLogLevel("LOG_LEVEL", slog.LevelInfo, &errs),
+		HTTP: HTTP{
+			Address:         envString("HTTP_ADDRESS", ":8080"),
+			ReadTimeout:     envDuration("HTTP_READ_TIMEOUT", 10*time.Second, &errs),
+			WriteTimeout:    envDuration("HTTP_WRITE_TIMEOUT", 10*time.Second, &errs),
+			ShutdownTimeout: envDuration("HTTP_SHUTDOWN_TIMEOUT", 15*time.Second, &errs),
+		},
+		Storage: Storage{
+			Driver: envString("STORAGE_DRIVER", StorageDriverPostgres),
+		},
+		Postgres: Postgres{
+			URL: envString("POSTGRES_URL", ""),
+		},
+		Sessions: Sessions{
+			MaxTokenRetries:     envInt("SESSION_MAX_TOKEN_RETRIES", 3, &errs),
+			Duration:            envDuration("SESSION_DURATION", 30*24*time.Hour, &errs),
+			AccessTokenDuration: envDuration("ACCESS_TOKEN_DURATION", 15*time.Minute, &errs),
+			RefreshTokenPepper:  envRequired("SESSION_TOKEN_PEPPER", &errs),
+			Issuer:              envString("ACCESS_TOKEN_ISSUER", "users-api"),
+			Audience:            envList("ACCESS_TOKEN_AUDIENCE", []string{"users-api"}),
+			ClientId:            envString("ACCESS_TOKEN_CLIENT_ID", "users-api"),
+			Scopes:              envList("ACCESS_TOKEN_SCOPES", nil),
+			SigningKeyFile:      envString("ACCESS_TOKEN_SIGNING_KEY_FILE", ""),
+			SigningKeyDir:       envString("ACCESS_TOKEN_SIGNING_KEY_DIR", ""),
+			SigningAlgorithm:    envString("ACCESS_TOKEN_SIGNING_ALGORITHM", "EdDSA"),
+			EphemeralKeys:       envBool("ACCESS_TOKEN_EPHEMERAL_KEYS", false, &errs),
+			KeyRotationInterval: envDuration("ACCESS_TOKEN_KEY_ROTATION_INTERVAL", 0, &errs),
+			KeyReloadInterval:   envDuration("ACCESS_TOKEN_KEY_RELOAD_INTERVAL", time.Minute, &errs),
+			KeyPublishDelay:     envDuration("ACCESS_TOKEN_KEY_PUBLISH_DELAY", 10*time.Minute, &errs),
+			ReaperInterval:      envDuration("SESSION_REAPER_INTERVAL", 10*time.Minute, &errs),
+			ReaperBatchSize:     envInt("SESSION_REAPER_BATCH_SIZE", 1000, &errs),
+			Policy:              envString("SESSION_POLICY", "unlimited"),
+			MaxPerUser:          envInt("SESSION_MAX_PER_USER", 5, &errs),
+		},
+		Passwords: Passwords{
+			HashAlgorithm:     envString("PASSWORD_HASH_ALGORITHM", "argon2id"),
+			Argon2Memory:      envInt("PASSWORD_ARGON2_MEMORY", 19*1024, &errs),
+			Argon2Iterations:  envInt("PASSWORD_ARGON2_ITERATIONS", 2, &errs),
+			Argon2Parallelism: envInt("PASSWORD_ARGON2_PARALLELISM", 1, &errs),
+			BcryptCost:        envInt("PASSWORD_BCRYPT_COST", 12, &errs),
+			BreachCorpus:      envString("PASSWORD_BREACH_CORPUS", ""),
+			BreachPolicy:      envString("PASSWORD_BREACH_POLICY", "reject"),
+			BreachMinCount:    envInt("PASSWORD_BREACH_MIN_COUNT", 1, &errs),
+			MinLength:         envInt("PASSWORD_MIN_LENGTH", 6, &errs),
+			MaxLength:         envInt("PASSWORD_MAX_LENGTH", 1024, &errs),
+			RequiredClasses:   envList("PASSWORD_REQUIRED_CLASSES", nil),
+			MinClasses:        envInt("PASSWORD_MIN_CLASSES", 2, &errs),
+			BannedSubstrings:  envList("PASSWORD_BANNED_SUBSTRINGS", nil),
+			BanUserInputs:     envBool("PASSWORD_BAN_USER_INPUTS", true, &errs),
+			MinStrength:       envInt("PASSWORD_MIN_STRENGTH", 0, &errs),
+		},
+		Introspection: Introspection{
+			Clients: envCredentials("INTROSPECTION_CLIENTS", &errs),
+		},
+		EmailConfirmation: EmailConfirmation{
+			TokenDuration:  envDuration("EMAIL_CONFIRMATION_TOKEN_DURATION", 24*time.Hour, &errs),
+			ResendCooldown: envDuration("EMAIL_CONFIRMATION_RESEND_COOLDOWN", time.Minute, &errs),
+			LinkTemplate:   envString("EMAIL_CONFIRMATION_LINK", "http://localhost:3000/confirm-email?token={token}"),
+			Policy:         envString("EMAIL_CONFIRMATION_POLICY", "optional"),
+		},
+		PasswordReset: PasswordReset{
+			TokenDuration:   envDuration("PASSWORD_RESET_TOKEN_DURATION", 30*time.Minute, &errs),
+			RequestCooldown: envDuration("PASSWORD_RESET_REQUEST_COOLDOWN", time.Minute, &errs),
+			LinkTemplate:    envString("PASSWORD_RESET_LINK", "http://localhost:3000/reset-password?token={token}"),
+		},
+		Mail: Mail{
+			Driver:        envString("MAIL_DRIVER", MailDriverLog),
+			From:          envString("MAIL_FROM", "users-api <no-reply@localhost>"),
+			TemplateDir:   envString("MAIL_TEMPLATE_DIR", ""),
+			DefaultLocale: envString("MAIL_DEFAULT_LOCALE", defaultLocale),
+			SMTPHost:      envString("MAIL_SMTP_HOST", ""),
+			SMTPPort:      envInt("MAIL_SMTP_PORT", 587, &errs),
+			SMTPUsername:  envString("MAIL_SMTP_USERNAME", ""),
+			SMTPPassword:  envString("MAIL_SMTP_PASSWORD", ""),
+			SMTPSecurity:  envString("MAIL_SMTP_SECURITY", "starttls"),
+			FileDir:       envString("MAIL_FILE_DIR", ""),
+			QueueSize:     envInt("MAIL_QUEUE_SIZE", 256, &errs),
+			Workers:       envInt("MAIL_WORKERS", 4, &errs),
+		},
+		Locales: Locales{
+			Default:    defaultLocale,
+			CatalogDir: envString("MESSAGE_CATALOG_DIR", ""),
+		},
+	}
+
+	switch cfg.Storage.Driver {
+	case StorageDriverPostgres:
+		if cfg.Postgres.URL == "" {
+			errs = append(errs, errors.New("POSTGRES_URL is required by the postgres storage driver"))
+		}
+	case StorageDriverMemory:
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE_DRIVER: unknown driver %q", cfg.Storage.Driver))
+	}
+
+	if cfg.Sessions.MaxTokenRetries < 1 {
+		errs = append(errs, errors.New("SESSION_MAX_TOKEN_RETRIES must be positive"))
+	}
+	if cfg.Sessions.ReaperInterval <= 0 {
+		errs = append(errs, errors.New("SESSION_REAPER_INTERVAL must be positive"))
+	}
+	if cfg.Sessions.ReaperBatchSize <= 0 {
+		errs = append(errs, errors.New("SESSION_REAPER_BATCH_SIZE must be positive"))
+	}
+
+	switch cfg.Sessions.Policy {
+	case "unlimited", "single":
+	case "limited":
+		if cfg.Sessions.MaxPerUser <= 0 {
+			errs = append(errs, errors.New("SESSION_MAX_PER_USER must be positive"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("SESSION_POLICY: unknown policy %q", cfg.Sessions.Policy))
+	}
+
+	switch cfg.Sessions.SigningAlgorithm {
+	case "RS256", "ES256", "EdDSA":
+	default:
+		errs = append(errs, fmt.Errorf("ACCESS_TOKEN_SIGNING_ALGORITHM: unknown algorithm %q", cfg.Sessions.SigningAlgorithm))
+	}
+	if len(cfg.Sessions.Audience) == 0 {
+		errs = append(errs, errors.New("ACCESS_TOKEN_AUDIENCE must name at least one audience"))
+	}
+	if cfg.Sessions.ClientId == "" {
+		errs = append(errs, errors.New("ACCESS_TOKEN_CLIENT_ID must not be empty"))
+	}
+	if cfg.Sessions.SigningKeyFile != "" && cfg.Sessions.SigningKeyDir != "" {
+		errs = append(errs, errors.New("ACCESS_TOKEN_SIGNING_KEY_FILE and ACCESS_TOKEN_SIGNING_KEY_DIR are mutually exclusive"))
+	}
+	if cfg.Sessions.SigningKeyFile == "" && cfg.Sessions.SigningKeyDir == "" && !cfg.Sessions.EphemeralKeys {
+		errs = append(errs, errors.New("ACCESS_TOKEN_SIGNING_KEY_FILE or ACCESS_TOKEN_SIGNING_KEY_DIR is required, or ACCESS_TOKEN_EPHEMERAL_KEYS=true for development"))
+	}
+	if cfg.Sessions.SigningKeyFile != "" && cfg.Sessions.KeyRotationInterval != 0 {
+		errs = append(errs, errors.New("ACCESS_TOKEN_KEY_ROTATION_INTERVAL requires ACCESS_TOKEN_SIGNING_KEY_DIR, a single key file cannot be rotated"))
+	}
+	if cfg.Sessions.KeyRotationInterval < 0 {
+		errs = append(errs, errors.New("ACCESS_TOKEN_KEY_ROTATION_INTERVAL must not be negative"))
+	}
+	if cfg.Sessions.KeyReloadInterval <= 0 {
+		errs = append(errs, errors.New("ACCESS_TOKEN_KEY_RELOAD_INTERVAL must be positive"))
+	}
+	if cfg.Sessions.KeyPublishDelay < cfg.Sessions.KeyReloadInterval {
+		errs = append(errs, errors.New("ACCESS_TOKEN_KEY_PUBLISH_DELAY must be at least ACCESS_TOKEN_KEY_RELOAD_INTERVAL, or replicas sign with keys others do not publish yet"))
+	}
+
+	switch cfg.Passwords.HashAlgorithm {
+	case "argon2id", "bcrypt":
+	default:
+		errs = append(errs, fmt.Errorf("PASSWORD_HASH_ALGORITHM: unknown algorithm %q", cfg.Passwords.HashAlgorithm))
+	}
+	if cfg.Passwords.Argon2Memory < 8*cfg.Passwords.Argon2Parallelism || cfg.Passwords.Argon2Memory > 1024*1024 {
+		errs = append(errs, errors.New("PASSWORD_ARGON2_MEMORY must be between 8 KiB per thread and 1 GiB"))
+	}
+	if cfg.Passwords.Argon2Iterations <= 0 || cfg.Passwords.Argon2Iterations > 16 {
+		errs = append(errs, errors.New("PASSWORD_ARGON2_ITERATIONS must be between 1 and 16"))
+	}
+	if cfg.Passwords.Argon2Parallelism <= 0 || cfg.Passwords.Argon2Parallelism > 255 {
+		errs = append(errs, errors.New("PASSWORD_ARGON2_PARALLELISM must be between 1 and 255"))
+	}
+	if cfg.Passwords.BcryptCost < 10 || cfg.Passwords.BcryptCost > 16 {
+		errs = append(errs, errors.New("PASSWORD_BCRYPT_COST must be between 10 and 16"))
+	}
+
+	switch cfg.Passwords.BreachPolicy {
+	case "reject", "warn":
+	default:
+		errs = append(errs, fmt.Errorf("PASSWORD_BREACH_POLICY: unknown policy %q", cfg.Passwords.BreachPolicy))
+	}
+	if cfg.Passwords.BreachMinCount <= 0 {
+		errs = append(errs, errors.New("PASSWORD_BREACH_MIN_COUNT must be positive"))
+	}
+
+	if cfg.Passwords.MinLength < 1 {
+		errs = append(errs, errors.New("PASSWORD_MIN_LENGTH must be positive"))
+	}
+	if cfg.Passwords.MaxLength < cfg.Passwords.MinLength {
+		errs = append(errs, errors.New("PASSWORD_MAX_LENGTH must not be below PASSWORD_MIN_LENGTH"))
+	}
+	for _, class := range cfg.Passwords.RequiredClasses {
+		switch class {
+		case "lower", "upper", "digit", "symbol":
+		default:
+			errs = append(errs, fmt.Errorf("PASSWORD_REQUIRED_CLASSES: unknown class %q", class))
+		}
+	}
+	if cfg.Passwords.MinClasses < 0 || cfg.Passwords.MinClasses > 4 {
+		errs = append(errs, errors.New("PASSWORD_MIN_CLASSES must be between 0 and 4"))
+	}
+	if cfg.Passwords.MinStrength < 0 || cfg.Passwords.MinStrength > 4 {
+		errs = append(errs, errors.New("PASSWORD_MIN_STRENGTH must be between 0 and 4"))
+	}
+
+	if cfg.EmailConfirmation.TokenDuration <= 0 {
+		errs = append(errs, errors.New("EMAIL_CONFIRMATION_TOKEN_DURATION must be positive"))
+	}
+	if !strings.Contains(cfg.EmailConfirmation.LinkTemplate, "{token}") {
+		errs = append(errs, errors.New("EMAIL_CONFIRMATION_LINK must contain {token}"))
+	}
+	switch cfg.EmailConfirmation.Policy {
+	case "optional", "restricted", "required":
+	default:
+		errs = append(errs, fmt.Errorf("EMAIL_CONFIRMATION_POLICY: unknown policy %q", cfg.EmailConfirmation.Policy))
+	}
+
+	if cfg.PasswordReset.TokenDuration <= 0 {
+		errs = append(errs, errors.New("PASSWORD_RESET_TOKEN_DURATION must be positive"))
+	}
+	if !strings.Contains(cfg.PasswordReset.LinkTemplate, "{token}") {
+		errs = append(errs, errors.New("PASSWORD_RESET_LINK must contain {token}"))
+	}
+
+	switch cfg.Mail.Driver {
+	case MailDriverLog:
+	case MailDriverSMTP:
+		if cfg.Mail.SMTPHost == "" {
+			errs = append(errs, errors.New("MAIL_SMTP_HOST is required by the smtp mail driver"))
+		}
+		if cfg.Mail.SMTPPort <= 0 || cfg.Mail.SMTPPort > 65535 {
+			errs = append(errs, errors.New("MAIL_SMTP_PORT must be a valid port"))
+		}
+		switch cfg.Mail.SMTPSecurity {
+		case "starttls", "tls", "none":
+		default:
+			errs = append(errs, fmt.Errorf("MAIL_SMTP_SECURITY: unknown mode %q", cfg.Mail.SMTPSecurity))
+		}
+	case MailDriverFile:
+		if cfg.Mail.FileDir == "" {
+			errs = append(errs, errors.New("MAIL_FILE_DIR is required by the file mail driver"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("MAIL_DRIVER: unknown driver %q", cfg.Mail.Driver))
+	}
+	if cfg.Mail.QueueSize <= 0 {
+		errs = append(errs, errors.New("MAIL_QUEUE_SIZE must be positive"))
+	}
+	if cfg.Mail.Workers <= 0 {
+		errs = append(errs, errors.New("MAIL_WORKERS must be positive"))
+	}
+
+	return cfg, errors.Join(errs...)
+}
+
+func envString(key string, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// envList parses a list separated by commas or whitespace.
+func envList(key string, fallback []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
+// envCredentials parses a comma-separated list of id:secret pairs.
+func envCredentials(key string, errs *[]error) map[string]string {
+	credentials := make(map[string]string)
+	for _, pair := range envList(key, nil) {
+		id, secret, ok := strings.Cut(pair, ":")
+		if !ok || id == "" || secret == "" {
+			*errs = append(*errs, fmt.Errorf("%s: expected id:secret pairs", key))
+			continue
+		}
+		credentials[id] = secret
+	}
+	return credentials
+}
+
+func envRequired(key string, errs *[]error) string {
+	value := os.Getenv(key)
+	if value == "" {
+		*errs = append(*errs, fmt.Errorf("%s is required", key))
+	}
+	return value
+}
+
+func envInt(key string, fallback int, errs *[]error) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: %w", key, err))
+		return fallback
+	}
+	return parsed
+}
+
+func envBool(key string, fallback bool, errs *[]error) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: %w", key, err))
+		return fallback
+	}
+	return parsed
+}
+
+func envDuration(key string, fallback time.Duration, errs *[]error) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: %w", key, err))
+		return fallback
+	}
+	return parsed
+}
+
+func envLogLevel(key string, fallback slog.Level, errs *[]error) slog.Level {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: %w", key, err))
+		return fallback
+	}
+	return level
+}
@@ -0,0 +1,89 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActionPurpose names what an ActionToken authorises.
+type ActionPurpose string
+
+const (
+	ActionEmailConfirmation ActionPurpose = "email_confirmation"
+	ActionPasswordReset     ActionPurpose = "password_reset"
+)
+
+// ActionToken is a single-use, expiring token mailed to a user to prove they
+// control their address, for example to confirm it or reset their password.
+// Like sessions, it only holds the keyed hash of the token it was issued for.
+type ActionToken struct {
+	id        uuid.UUID
+	user      uuid.UUID
+	purpose   ActionPurpose
+	tokenHash string
+	createdAt time.Time
+	expiresAt time.Time
+	usedAt    *time.Time
+}
+
+func (t ActionToken) Id() uuid.UUID {
+	return t.id
+}
+
+func (t ActionToken) User() uuid.UUID {
+	return t.user
+}
+
+func (t ActionToken) Purpose() ActionPurpose {
+	return t.purpose
+}
+
+func (t ActionToken) TokenHash() string {
+	return t.tokenHash
+}
+
+func (t ActionToken) CreatedAt() time.Time {
+	return t.createdAt
+}
+
+func (t ActionToken) ExpiresAt() time.Time {
+	return t.expiresAt
+}
+
+func (t ActionToken) UsedAt() *time.Time {
+	return t.usedAt
+}
+
+func NewActionToken(user uuid.UUID, purpose ActionPurpose, tokenHash string, duration time.Duration) ActionToken {
+	now := time.Now()
+
+	return ActionToken{
+		id:        uuid.New(),
+		user:      user,
+		purpose:   purpose,
+		tokenHash: tokenHash,
+		createdAt: now,
+		expiresAt: now.Add(duration),
+	}
+}
+
+func LoadActionToken(
+	id uuid.UUID,
+	user uuid.UUID,
+	purpose ActionPurpose,
+	tokenHash string,
+	createdAt time.Time,
+	expiresAt time.Time,
+	usedAt *time.Time,
+) ActionToken {
+	return ActionToken{
+		id:        id,
+		user:      user,
+		purpose:   purpose,
+		tokenHash: tokenHash,
+		createdAt: createdAt,
+		expiresAt: expiresAt,
+		usedAt:    usedAt,
+	}
+}
@@ -0,0 +1,10 @@
+package entities
+
+// Device describes the client a session was opened from. Name is supplied by
+// the client and purely informational; UserAgent and IP are recorded by the
+// server when the session is created.
+type Device struct {
+	Name      string
+	UserAgent string
+	IP        string
+}
@@ -7,7 +7,7 @@ type Email string
 func NewEmail(value string) (Email, error) {
 	addr, err := mail.ParseAddress(value)
 	if err != nil {
-		return "", newValidationError("email", "email is mailformed")
+		return "", newValidationError("email", CodeInvalidFormat, "should be a valid email address", nil)
 	}
 
 	return Email(addr.Address), nil
@@ -0,0 +1,10 @@
+package entities
+
+// Mail is an email message addressed to a single recipient. HTML is an
+// optional alternative to the plain Text body.
+type Mail struct {
+	To      Email
+	Subject string
+	Text    string
+	HTML    string
+}
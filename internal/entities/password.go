@@ -1,49 +1,28 @@
 package entities
 
-import (
-	"fmt"
-	"slices"
-	"unicode"
-
-	"golang.org/x/crypto/bcrypt"
-)
-
+// Password is the encoded hash of a user's password, never the password
+// itself.
 type Password string
 
+// PasswordHasher hashes passwords and verifies them against stored hashes.
+type PasswordHasher interface {
+	Hash(password string) (Password, error)
+	// Verify reports whether password matches hash and, if it does, whether
+	// hash was made with an outdated algorithm or parameters and should be
+	// replaced by a fresh Hash of the password.
+	Verify(hash Password, password string) (ok bool, rehash bool, err error)
+}
+
 func RawPassword(value string) Password {
 	return Password(value)
 }
 
-func NewPassword(value string) (Password, error) {
-	if len(value) < 6 {
-		return "", newValidationError("password", "should be at least 6 characters long")
-	}
-
-	charTypeCount := 0
-	if slices.ContainsFunc([]rune(value), unicode.IsLower) {
-		charTypeCount++
-	}
-
-	if slices.ContainsFunc([]rune(value), unicode.IsUpper) {
-		charTypeCount++
+// NewPassword checks a plain text password against policy and hashes it
+// with hasher. userInputs are passed on to PasswordPolicy.Validate.
+func NewPassword(value string, policy PasswordPolicy, hasher PasswordHasher, userInputs ...string) (Password, error) {
+	if err := policy.Validate(value, userInputs...); err != nil {
+		return "", err
 	}
 
-	if slices.ContainsFunc([]rune(value), unicode.IsDigit) {
-		charTypeCount++
-	}
-
-	if charTypeCount < 2 {
-		return "", newValidationError("password", "should contain at least two types of characters: lowercase, uppercase, digits")
-	}
-
-	hashed, err := bcrypt.GenerateFromPassword([]byte(value), bcrypt.DefaultCost)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate hash from password: %w", err)
-	}
-
-	return Password(hashed), nil
-}
-
-func (p Password) Compare(other string) bool {
-	return bcrypt.CompareHashAndPassword([]byte(p), []byte(other)) == nil
+	return hasher.Hash(value)
 }
@@ -0,0 +1,179 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CharacterClass is a kind of character a password policy can ask for.
+type CharacterClass string
+
+const (
+	ClassLower  CharacterClass = "lower"
+	ClassUpper  CharacterClass = "upper"
+	ClassDigit  CharacterClass = "digit"
+	ClassSymbol CharacterClass = "symbol"
+)
+
+var characterClasses = []CharacterClass{ClassLower, ClassUpper, ClassDigit, ClassSymbol}
+
+// PasswordPolicy are the rules passwords chosen by users have to follow.
+type PasswordPolicy struct {
+	// MinLength is counted in characters, MaxLength in bytes, which is what
+	// hashing limits such as bcrypt's 72 bytes are about. Zero disables
+	// MaxLength.
+	MinLength int
+	MaxLength int
+
+	// RequiredClasses must all appear, and at least MinClasses different
+	// classes must appear in total.
+	RequiredClasses []CharacterClass
+	MinClasses      int
+
+	// BannedSubstrings may not appear anywhere in the password, ignoring
+	// case. With BanUserInputs, neither may the user inputs passed to
+	// Validate, such as the username.
+	BannedSubstrings []string
+	BanUserInputs    bool
+
+	// MinStrength is the lowest acceptable score of Estimator, 0 to 4, which
+	// must be set when MinStrength is positive.
+	MinStrength int
+	Estimator   PasswordStrengthEstimator
+}
+
+// PasswordStrengthEstimator scores how hard a password is to guess in the
+// manner of zxcvbn, from 0, within a thousand guesses, to 4, beyond ten
+// billion. userInputs are strings the user is known by, which count as the
+// most common words of all.
+type PasswordStrengthEstimator interface {
+	EstimateStrength(password string, userInputs ...string) int
+}
+
+// DefaultPasswordPolicy asks for six characters of two classes.
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:     6,
+	MaxLength:     1024,
+	MinClasses:    2,
+	BanUserInputs: true,
+}
+
+// minUserInputLength keeps short user inputs, which would match by accident,
+// out of the banned substrings.
+const minUserInputLength = 3
+
+// ParseCharacterClass recognises the name of a CharacterClass.
+func ParseCharacterClass(value string) (CharacterClass, error) {
+	for _, class := range characterClasses {
+		if string(class) == value {
+			return class, nil
+		}
+	}
+	return "", fmt.Errorf("unknown character class %q", value)
+}
+
+// Validate checks password against every rule and returns all violations
+// joined, so users can fix them at once. userInputs are strings the user
+// is known by, such as their username and the local part of their email
+// address.
+func (p PasswordPolicy) Validate(password string, userInputs ...string) error {
+	var errs []error
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		errs = append(errs, newValidationError(
+			"password", CodeTooShort,
+			fmt.Sprintf("should be at least %d characters long", p.MinLength),
+			ValidationParams{"min": p.MinLength},
+		))
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		errs = append(errs, newValidationError(
+			"password", CodeTooLong,
+			fmt.Sprintf("should be at most %d bytes long", p.MaxLength),
+			ValidationParams{"max": p.MaxLength},
+		))
+	}
+
+	present := passwordClasses(password)
+	for _, class := range p.RequiredClasses {
+		if !present[class] {
+			errs = append(errs, newValidationError(
+				"password", CodeMissingCharacterClass,
+				fmt.Sprintf("should contain %s character", classDescription(class)),
+				ValidationParams{"class": class},
+			))
+		}
+	}
+	if len(present) < p.MinClasses {
+		errs = append(errs, newValidationError(
+			"password", CodeTooFewCharacterClasses,
+			fmt.Sprintf(
+				"should contain at least %d types of characters: lowercase, uppercase, digits, symbols",
+				p.MinClasses,
+			),
+			ValidationParams{"min": p.MinClasses},
+		))
+	}
+
+	lower := strings.ToLower(password)
+	for _, banned := range p.BannedSubstrings {
+		if banned != "" && strings.Contains(lower, strings.ToLower(banned)) {
+			errs = append(errs, newValidationError(
+				"password", CodeBannedSubstring, "should not contain commonly guessed words", nil,
+			))
+			break
+		}
+	}
+	if p.BanUserInputs {
+		for _, input := range userInputs {
+			if len(input) >= minUserInputLength && strings.Contains(lower, strings.ToLower(input)) {
+				errs = append(errs, newValidationError(
+					"password", CodeContainsUserInput, "should not contain your username or email", nil,
+				))
+				break
+			}
+		}
+	}
+
+	if p.MinStrength > 0 {
+		if score := p.Estimator.EstimateStrength(password, userInputs...); score < p.MinStrength {
+			errs = append(errs, newValidationError(
+				"password", CodeTooWeak, "is too easy to guess",
+				ValidationParams{"min": p.MinStrength, "score": score},
+			))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func passwordClasses(password string) map[CharacterClass]bool {
+	present := make(map[CharacterClass]bool)
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			present[ClassLower] = true
+		case unicode.IsUpper(r):
+			present[ClassUpper] = true
+		case unicode.IsDigit(r):
+			present[ClassDigit] = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || r == ' ':
+			present[ClassSymbol] = true
+		}
+	}
+	return present
+}
+
+func classDescription(class CharacterClass) string {
+	switch class {
+	case ClassLower:
+		return "a lowercase"
+	case ClassUpper:
+		return "an uppercase"
+	default:
+		return "a " + string(class)
+	}
+}
@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SecurityEventKind string
+
+const (
+	// SecurityEventRefreshTokenReuse is raised when a refresh token that was
+	// already rotated away is presented again, which means it has most
+	// likely been stolen.
+	SecurityEventRefreshTokenReuse SecurityEventKind = "refresh_token_reuse"
+)
+
+type SecurityEvent struct {
+	Kind       SecurityEventKind
+	User       uuid.UUID
+	Session    uuid.UUID
+	OccurredAt time.Time
+}
+
+func NewSecurityEvent(kind SecurityEventKind, user uuid.UUID, session uuid.UUID) SecurityEvent {
+	return SecurityEvent{
+		Kind:       kind,
+		User:       user,
+		Session:    session,
+		OccurredAt: time.Now(),
+	}
+}
@@ -6,38 +6,84 @@ import (
 	"github.com/google/uuid"
 )
 
+// Session is a login of a user. Its id stays the same for the whole life of
+// the session while the refresh token is rotated on every refresh, so a
+// session doubles as the family of all refresh tokens issued for one login.
+//
+// A session never holds the refresh token itself, only its keyed hash: the
+// plaintext is handed to the client once and is not persisted anywhere.
 type Session struct {
-	id          uuid.UUID
-	user        uuid.UUID
-	token       string
-	createdAt   time.Time
-	refreshedAt time.Time
-	expiresAt   time.Time
+	id               uuid.UUID
+	user             uuid.UUID
+	tokenHash        string
+	rotatedTokenHash string
+	device           Device
+	createdAt        time.Time
+	refreshedAt      time.Time
+	expiresAt        time.Time
+	revokedAt        *time.Time
+}
+
+func (s Session) Id() uuid.UUID {
+	return s.id
+}
+
+func (s Session) Device() Device {
+	return s.device
+}
+
+func (s Session) CreatedAt() time.Time {
+	return s.createdAt
+}
+
+func (s Session) RefreshedAt() time.Time {
+	return s.refreshedAt
 }
 
 func (s Session) ExpiresAt() time.Time {
 	return s.expiresAt
 }
 
-func (s Session) Token() string {
-	return s.token
+func (s Session) IsExpired(now time.Time) bool {
+	return !now.Before(s.expiresAt)
+}
+
+func (s Session) RevokedAt() *time.Time {
+	return s.revokedAt
+}
+
+func (s Session) IsRevoked() bool {
+	return s.revokedAt != nil
+}
+
+func (s Session) TokenHash() string {
+	return s.tokenHash
+}
+
+// RotatedTokenHash returns the token hash replaced by the last call to
+// Refresh, or an empty string if the session has not been refreshed since it
+// was loaded.
+func (s Session) RotatedTokenHash() string {
+	return s.rotatedTokenHash
 }
 
 func (s Session) User() uuid.UUID {
 	return s.user
 }
 
-func (s *Session) Refresh(newToken string, duration time.Duration) {
-	s.token = newToken
+func (s *Session) Refresh(newTokenHash string, duration time.Duration) {
+	s.rotatedTokenHash = s.tokenHash
+	s.tokenHash = newTokenHash
 	s.refreshedAt = time.Now()
 	s.expiresAt = time.Now().Add(duration)
 }
 
-func NewSession(user uuid.UUID, token string, duration time.Duration) Session {
+func NewSession(user uuid.UUID, tokenHash string, device Device, duration time.Duration) Session {
 	return Session{
 		id:          uuid.New(),
 		user:        user,
-		token:       token,
+		tokenHash:   tokenHash,
+		device:      device,
 		createdAt:   time.Now(),
 		refreshedAt: time.Now(),
 		expiresAt:   time.Now().Add(duration),
@@ -47,17 +93,21 @@ func NewSession(user uuid.UUID, token string, duration time.Duration) Session {
 func LoadSession(
 	id uuid.UUID,
 	user uuid.UUID,
-	token string,
+	tokenHash string,
+	device Device,
 	createdAt time.Time,
 	refreshedAt time.Time,
 	expiresAt time.Time,
+	revokedAt *time.Time,
 ) Session {
 	return Session{
 		id:          id,
 		user:        user,
-		token:       token,
+		tokenHash:   tokenHash,
+		device:      device,
 		createdAt:   createdAt,
 		refreshedAt: refreshedAt,
 		expiresAt:   expiresAt,
+		revokedAt:   revokedAt,
 	}
 }
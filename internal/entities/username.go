@@ -1,19 +1,26 @@
 package entities
 
 import (
+	"fmt"
 	"strings"
 	"unicode"
 )
 
+const minUsernameLength = 3
+
 type Username string
 
 func NewUsername(value string) (Username, error) {
-	if len(value) < 3 {
-		return "", newValidationError("username", "should be at least 3 characters long")
+	if len(value) < minUsernameLength {
+		return "", newValidationError(
+			"username", CodeTooShort,
+			fmt.Sprintf("should be at least %d characters long", minUsernameLength),
+			ValidationParams{"min": minUsernameLength},
+		)
 	}
 
 	if strings.ContainsFunc(value, unicode.IsSpace) {
-		return "", newValidationError("username", "should not contain spaces")
+		return "", newValidationError("username", CodeContainsWhitespace, "should not contain spaces", nil)
 	}
 
 	return Username(value), nil
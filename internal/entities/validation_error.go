@@ -1,20 +1,77 @@
 package entities
 
+import "fmt"
+
+// ValidationCode identifies the rule an input broke. Codes are stable, so
+// clients can branch on them and translate them, unlike messages.
+type ValidationCode string
+
+const (
+	// CodeTooShort has the minimum length as "min".
+	CodeTooShort ValidationCode = "too_short"
+	// CodeTooLong has the maximum length as "max".
+	CodeTooLong            ValidationCode = "too_long"
+	CodeInvalidFormat      ValidationCode = "invalid_format"
+	CodeContainsWhitespace ValidationCode = "contains_whitespace"
+	// CodeMissingCharacterClass has the missing CharacterClass as "class".
+	CodeMissingCharacterClass ValidationCode = "missing_character_class"
+	// CodeTooFewCharacterClasses has the number of classes needed as "min".
+	CodeTooFewCharacterClasses ValidationCode = "too_few_character_classes"
+	CodeBannedSubstring        ValidationCode = "banned_substring"
+	CodeContainsUserInput      ValidationCode = "contains_user_input"
+	// CodeTooWeak has the score needed as "min" and the one reached as
+	// "score".
+	CodeTooWeak   ValidationCode = "too_weak"
+	CodeBreached  ValidationCode = "breached"
+	CodeUnchanged ValidationCode = "unchanged"
+)
+
+// ValidationParams are the values a rule was checked against, such as a
+// minimum length, keyed as documented on each ValidationCode.
+type ValidationParams map[string]any
+
 type ValidationError struct {
+	// Field is the path of the offending input, nested names separated by
+	// dots.
 	Field   string
+	Code    ValidationCode
 	Message string
+	Params  ValidationParams
 }
 
 var _ error = (*ValidationError)(nil)
 
 // Error implements error.
 func (v *ValidationError) Error() string {
-	panic("unimplemented")
+	return fmt.Sprintf("%s: %s", v.Field, v.Message)
 }
 
-func newValidationError(field, message string) *ValidationError {
+func newValidationError(field string, code ValidationCode, message string, params ValidationParams) *ValidationError {
 	return &ValidationError{
 		Field:   field,
+		Code:    code,
 		Message: message,
+		Params:  params,
+	}
+}
+
+// ValidationErrors collects every ValidationError in err, which may be a
+// tree built by errors.Join and fmt.Errorf, in order.
+func ValidationErrors(err error) []*ValidationError {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *ValidationError:
+		return []*ValidationError{e}
+	case interface{ Unwrap() []error }:
+		var found []*ValidationError
+		for _, inner := range e.Unwrap() {
+			found = append(found, ValidationErrors(inner)...)
+		}
+		return found
+	case interface{ Unwrap() error }:
+		return ValidationErrors(e.Unwrap())
+	default:
+		return nil
 	}
 }
@@ -0,0 +1,34 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// LogPublisher writes security events to a structured log, where they can be
+// picked up by whatever collects and alerts on the service logs.
+type LogPublisher struct {
+	logger *slog.Logger
+}
+
+var _ ports.SecurityEventPublisher = (*LogPublisher)(nil)
+
+func NewLogPublisher(logger *slog.Logger) *LogPublisher {
+	return &LogPublisher{
+		logger: logger,
+	}
+}
+
+func (p LogPublisher) PublishSecurityEvent(ctx context.Context, event entities.SecurityEvent) error {
+	p.logger.WarnContext(
+		ctx, "Security event",
+		slog.String("kind", string(event.Kind)),
+		slog.String("user_id", event.User.String()),
+		slog.String("session_id", event.Session.String()),
+		slog.Time("occurred_at", event.OccurredAt),
+	)
+	return nil
+}
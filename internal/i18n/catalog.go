@@ -0,0 +1,233 @@
+// Package i18n translates the messages users see, such as validation errors
+// and problem details, from per-locale catalogs keyed by stable codes.
+//
+// A catalog is a JSON file named after its locale, e.g. "ru.json", mapping
+// keys to messages. Messages refer to parameters as "{name}". Where the
+// wording depends on a parameter, the message is an object instead: with
+// "plural" naming a numeric parameter, it holds a message per CLDR plural
+// form ("zero", "one", "two", "few", "many"); with "select" naming any
+// other parameter, it holds a message per value. Either needs an "other"
+// message to fall back to.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+//go:embed catalogs
+var builtin embed.FS
+
+// Catalog holds the messages of every locale and picks the locale for
+// requests.
+type Catalog struct {
+	defaultLocale language.Tag
+	// locales lists the locales with a catalog, the default one first, as
+	// the matcher expects.
+	locales  []language.Tag
+	matcher  language.Matcher
+	messages map[language.Tag]map[string]message
+}
+
+type message struct {
+	text string
+	// param names the parameter choosing between forms, counted for
+	// plural messages and compared for select messages.
+	param  string
+	plural bool
+	forms  map[string]string
+}
+
+// BuiltinCatalogs returns the catalogs shipped with the service.
+func BuiltinCatalogs() fs.FS {
+	fsys, err := fs.Sub(builtin, "catalogs")
+	if err != nil {
+		panic(err)
+	}
+	return fsys
+}
+
+// NewCatalog loads every catalog in fsys. Each key has to exist in the
+// default locale, which is what other locales fall back to.
+func NewCatalog(fsys fs.FS, defaultLocale string) (*Catalog, error) {
+	defaultTag, err := language.Parse(defaultLocale)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default locale %q: %w", defaultLocale, err)
+	}
+
+	c := &Catalog{
+		defaultLocale: defaultTag,
+		messages:      make(map[language.Tag]map[string]message),
+	}
+
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message catalogs: %w", err)
+	}
+
+	for _, file := range files {
+		tag, err := language.Parse(strings.TrimSuffix(file, path.Ext(file)))
+		if err != nil {
+			return nil, fmt.Errorf("message catalog %s is not named after a locale: %w", file, err)
+		}
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message catalog %s: %w", file, err)
+		}
+		messages, err := parseCatalog(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse message catalog %s: %w", file, err)
+		}
+
+		c.messages[tag] = messages
+		if tag != defaultTag {
+			c.locales = append(c.locales, tag)
+		}
+	}
+
+	defaults, ok := c.messages[defaultTag]
+	if !ok {
+		return nil, fmt.Errorf("no message catalog for the default locale %q", defaultLocale)
+	}
+	for tag, messages := range c.messages {
+		for key := range messages {
+			if _, ok := defaults[key]; !ok {
+				return nil, fmt.Errorf("message %q of locale %s is missing from the default locale", key, tag)
+			}
+		}
+	}
+
+	c.locales = append([]language.Tag{defaultTag}, c.locales...)
+	c.matcher = language.NewMatcher(c.locales)
+	return c, nil
+}
+
+func parseCatalog(data []byte) (map[string]message, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	messages := make(map[string]message, len(raw))
+	for key, value := range raw {
+		var text string
+		if err := json.Unmarshal(value, &text); err == nil {
+			messages[key] = message{text: text}
+			continue
+		}
+
+		var forms map[string]string
+		if err := json.Unmarshal(value, &forms); err != nil {
+			return nil, fmt.Errorf("message %q is neither a string nor an object of strings", key)
+		}
+
+		m := message{forms: forms}
+		switch {
+		case forms["plural"] != "":
+			m.param, m.plural = forms["plural"], true
+		case forms["select"] != "":
+			m.param = forms["select"]
+		default:
+			return nil, fmt.Errorf("message %q names neither a plural nor a select parameter", key)
+		}
+		if _, ok := forms["other"]; !ok {
+			return nil, fmt.Errorf("message %q has no other form", key)
+		}
+		delete(forms, "plural")
+		delete(forms, "select")
+		messages[key] = m
+	}
+	return messages, nil
+}
+
+// Negotiate picks the locale with a catalog that suits the Accept-Language
+// header best, or the default locale.
+func (c *Catalog) Negotiate(acceptLanguage string) string {
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	_, index, _ := c.matcher.Match(tags...)
+	return c.locales[index].String()
+}
+
+// Translate renders the message key of locale with params, falling back to
+// the language alone and then to the default locale. It reports false for
+// unknown keys.
+func (c *Catalog) Translate(locale string, key string, params map[string]any) (string, bool) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		tag = c.defaultLocale
+	}
+
+	m, ok := c.messages[tag][key]
+	if !ok {
+		base, _ := tag.Base()
+		tag = language.Make(base.String())
+		m, ok = c.messages[tag][key]
+	}
+	if !ok {
+		tag = c.defaultLocale
+		m, ok = c.messages[tag][key]
+	}
+	if !ok {
+		return "", false
+	}
+
+	text := m.text
+	if m.forms != nil {
+		text = m.forms["other"]
+		if form, ok := m.forms[m.form(tag, params[m.param])]; ok {
+			text = form
+		}
+	}
+
+	replacements := make([]string, 0, 2*len(params))
+	for name, value := range params {
+		replacements = append(replacements, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(replacements...).Replace(text), true
+}
+
+// form names the form of m for value, the plural category of a number or
+// the value itself.
+func (m message) form(tag language.Tag, value any) string {
+	if !m.plural {
+		return fmt.Sprint(value)
+	}
+
+	var n int
+	switch v := value.(type) {
+	case int:
+		n = v
+	case int64:
+		n = int(v)
+	case float64:
+		n = int(v)
+	default:
+		return "other"
+	}
+	if n < 0 {
+		n = -n
+	}
+
+	switch plural.Cardinal.MatchPlural(tag, n, 0, 0, 0, 0) {
+	case plural.Zero:
+		return "zero"
+	case plural.One:
+		return "one"
+	case plural.Two:
+		return "two"
+	case plural.Few:
+		return "few"
+	case plural.Many:
+		return "many"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,152 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var ErrUnsupportedKey = errors.New("unsupported signing key")
+
+// Key is an asymmetric key access tokens are signed with. Its ID is the
+// RFC 7638 thumbprint of the public key and ends up in the "kid" header of
+// every token it signs.
+type Key struct {
+	id        string
+	algorithm string
+	private   crypto.Signer
+}
+
+func (k Key) Id() string {
+	return k.id
+}
+
+func (k Key) Algorithm() string {
+	return k.algorithm
+}
+
+func (k Key) Private() crypto.Signer {
+	return k.private
+}
+
+func (k Key) Public() crypto.PublicKey {
+	return k.private.Public()
+}
+
+func (k Key) SigningMethod() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.algorithm)
+}
+
+// New wraps a private key, inferring the signing algorithm from its type:
+// RSA keys sign with RS256, P-256 keys with ES256 and Ed25519 keys with EdDSA.
+func New(private crypto.Signer) (Key, error) {
+	var algorithm string
+	switch key := private.(type) {
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < 2048 {
+			return Key{}, fmt.Errorf("%w: RSA keys must be at least 2048 bits", ErrUnsupportedKey)
+		}
+		algorithm = AlgorithmRS256
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return Key{}, fmt.Errorf("%w: only P-256 ECDSA keys are supported", ErrUnsupportedKey)
+		}
+		algorithm = AlgorithmES256
+	case ed25519.PrivateKey:
+		algorithm = AlgorithmEdDSA
+	default:
+		return Key{}, fmt.Errorf("%w: %T", ErrUnsupportedKey, private)
+	}
+
+	key := Key{algorithm: algorithm, private: private}
+
+	id, err := thumbprint(key.JWK())
+	if err != nil {
+		return Key{}, err
+	}
+	key.id = id
+
+	return key, nil
+}
+
+// Generate creates a fresh key for the given algorithm.
+func Generate(algorithm string) (Key, error) {
+	var (
+		private crypto.Signer
+		err     error
+	)
+
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return Key{}, fmt.Errorf("%w: unknown algorithm %q", ErrUnsupportedKey, algorithm)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to generate %s key: %w", algorithm, err)
+	}
+
+	return New(private)
+}
+
+// ParsePEM reads a private key in PKCS #8, PKCS #1 or SEC 1 PEM encoding.
+func ParsePEM(data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, errors.New("no PEM block found")
+	}
+
+	var (
+		private any
+		err     error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return Key{}, fmt.Errorf("%w: unexpected PEM block %q", ErrUnsupportedKey, block.Type)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to parse %s: %w", block.Type, err)
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return Key{}, fmt.Errorf("%w: %T", ErrUnsupportedKey, private)
+	}
+
+	return New(signer)
+}
+
+// MarshalPEM encodes the private key as PKCS #8 PEM.
+func (k Key) MarshalPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.private)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
@@ -0,0 +1,139 @@
+package keys
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Ring holds the key new tokens are signed with along with every key whose
+// tokens are still accepted. When the active key is replaced it stays
+// published for the overlap window, so tokens it signed remain verifiable
+// until they expire. Keys can also be published ahead of becoming active.
+//
+// A Ring is safe for concurrent use.
+type Ring struct {
+	mu      sync.RWMutex
+	overlap time.Duration
+
+	active Key
+	// published maps key ids to the time they stop being published; the zero
+	// time means the key is published for as long as its source holds it.
+	published map[string]publishedKey
+}
+
+type publishedKey struct {
+	key   Key
+	until time.Time
+}
+
+func NewRing(overlap time.Duration, bundle Bundle) *Ring {
+	r := &Ring{overlap: overlap}
+	r.Replace(bundle)
+	return r
+}
+
+// Active returns the key new tokens are signed with.
+func (r *Ring) Active() Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.active
+}
+
+// Lookup finds a published key by its id.
+func (r *Ring) Lookup(id string) (Key, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	published, ok := r.published[id]
+	if !ok || published.expired(time.Now()) {
+		return Key{}, false
+	}
+	return published.key, true
+}
+
+// JWKS returns the public halves of every published key, the active one
+// first.
+func (r *Ring) JWKS() JWKS {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	jwks := JWKS{Keys: []JWK{r.active.JWK()}}
+	for id, published := range r.published {
+		if id == r.active.id || published.expired(now) {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, published.key.JWK())
+	}
+	return jwks
+}
+
+// Publish adds key to the published keys without signing with it, so it can
+// be made active by Rotate once verifiers have picked it up.
+func (r *Ring) Publish(key Key) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.published[key.id] = publishedKey{key: key}
+}
+
+// Rotate makes next the active key and retires the current one.
+func (r *Ring) Rotate(next Key) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.retire(now)
+	r.active = next
+	r.published[next.id] = publishedKey{key: next}
+	r.prune(now)
+}
+
+// Replace swaps the ring's contents for a freshly loaded bundle. Keys that
+// are no longer in the bundle are dropped, except for the previously active
+// key and keys still inside their overlap window.
+func (r *Ring) Replace(bundle Bundle) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	published := make(map[string]publishedKey, len(bundle.Pending)+len(bundle.Retired)+2)
+
+	if r.published != nil && r.active.id != bundle.Active.id {
+		r.retire(now)
+	}
+	for id, key := range r.published {
+		if !key.until.IsZero() {
+			published[id] = key
+		}
+	}
+
+	for _, key := range slices.Concat(bundle.Pending, bundle.Retired) {
+		published[key.id] = publishedKey{key: key}
+	}
+	published[bundle.Active.id] = publishedKey{key: bundle.Active}
+
+	r.active = bundle.Active
+	r.published = published
+	r.prune(now)
+}
+
+// retire starts the overlap window of the active key. Callers hold the lock.
+func (r *Ring) retire(now time.Time) {
+	r.published[r.active.id] = publishedKey{key: r.active, until: now.Add(r.overlap)}
+}
+
+// prune drops keys whose overlap window has passed. Callers hold the lock.
+func (r *Ring) prune(now time.Time) {
+	for id, key := range r.published {
+		if key.expired(now) {
+			delete(r.published, id)
+		}
+	}
+}
+
+func (k publishedKey) expired(now time.Time) bool {
+	return !k.until.IsZero() && !now.Before(k.until)
+}
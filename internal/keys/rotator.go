@@ -0,0 +1,263 @@
+package keys
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+var ErrRotationUnsupported = errors.New("key source does not support rotation")
+
+// Rotator keeps a Ring in sync with its Source and rotates the signing key
+// once it is older than the rotation interval. A new key is published for the
+// publish delay before it becomes active, so verifiers caching the published
+// keys and other replicas reloading the source pick it up before tokens
+// signed with it appear. Without a source, keys are generated in memory and
+// do not survive a restart.
+type Rotator struct {
+	logger *slog.Logger
+	ring   *Ring
+	source Source
+
+	algorithm      string
+	interval       time.Duration
+	reloadInterval time.Duration
+	publishDelay   time.Duration
+
+	// mu serialises reloads and rotations triggered by the schedule and by
+	// the admin signal.
+	mu      sync.Mutex
+	since   time.Time
+	pending bool
+	// next is the key published in memory that becomes active at nextFrom;
+	// a Dir keeps its pending keys itself.
+	next     Key
+	nextFrom time.Time
+}
+
+// NewRotator loads the initial keys and returns the rotator along with the
+// ring it maintains. An empty Dir is seeded with a freshly generated key.
+func NewRotator(
+	logger *slog.Logger,
+	source Source,
+	overlap time.Duration,
+	algorithm string,
+	interval time.Duration,
+	reloadInterval time.Duration,
+	publishDelay time.Duration,
+) (*Rotator, *Ring, error) {
+	r := &Rotator{
+		logger:         logger,
+		source:         source,
+		algorithm:      algorithm,
+		interval:       interval,
+		reloadInterval: reloadInterval,
+		publishDelay:   publishDelay,
+	}
+
+	bundle, err := r.load()
+	if errors.Is(err, ErrNoKeys) {
+		// Nothing can have cached keys of an empty directory, so the first
+		// key is active right away.
+		logger.Warn("Key directory is empty, generating a signing key", slog.Any("source", source))
+		bundle, err = r.generate(time.Time{}, time.Now())
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.ring = NewRing(overlap, bundle)
+	r.since = bundle.Since
+	r.pending = len(bundle.Pending) > 0
+
+	logger.Info(
+		"Loaded signing keys",
+		slog.String("kid", bundle.Active.id),
+		slog.String("algorithm", bundle.Active.algorithm),
+		slog.Int("retired", len(bundle.Retired)),
+	)
+
+	return r, r.ring, nil
+}
+
+// Reload re-reads the source, picking up keys added or removed since the
+// last load.
+func (r *Rotator) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.reload()
+}
+
+// Rotate generates a new signing key and publishes it, making it active once
+// the publish delay has passed. It does nothing while a key is pending.
+func (r *Rotator) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rotate()
+}
+
+func (r *Rotator) reload() error {
+	if r.source == nil {
+		return nil
+	}
+
+	bundle, err := r.source.Load()
+	if err != nil {
+		return err
+	}
+
+	previous := r.ring.Active()
+	r.ring.Replace(bundle)
+	r.since = bundle.Since
+	r.pending = len(bundle.Pending) > 0
+
+	if previous.id != bundle.Active.id {
+		r.logger.Info(
+			"Signing key rotated",
+			slog.String("kid", bundle.Active.id),
+			slog.String("previous_kid", previous.id),
+		)
+	}
+
+	return nil
+}
+
+func (r *Rotator) rotate() error {
+	if r.pending {
+		return nil
+	}
+	activeFrom := time.Now().Add(r.publishDelay)
+
+	if r.source == nil {
+		key, err := Generate(r.algorithm)
+		if err != nil {
+			return err
+		}
+
+		r.ring.Publish(key)
+		r.pending = true
+		r.next, r.nextFrom = key, activeFrom
+
+		r.logger.Info("Published next signing key", slog.String("kid", key.id), slog.Time("active_from", activeFrom))
+		return nil
+	}
+
+	if _, err := r.generate(r.since, activeFrom); err != nil {
+		return err
+	}
+	return r.reload()
+}
+
+// activateNext makes the key published in memory active once it is due.
+func (r *Rotator) activateNext(now time.Time) {
+	if !r.pending || r.source != nil || now.Before(r.nextFrom) {
+		return
+	}
+
+	previous := r.ring.Active()
+	r.ring.Rotate(r.next)
+	r.since = r.nextFrom
+	r.pending = false
+
+	r.logger.Info("Signing key rotated", slog.String("kid", r.next.id), slog.String("previous_kid", previous.id))
+}
+
+// Run reloads the source every reload interval and rotates the signing key
+// when it is due, until ctx is cancelled.
+func (r *Rotator) Run(ctx context.Context) {
+	if r.source == nil && r.interval <= 0 {
+		return
+	}
+	tick := r.reloadInterval
+	if r.interval > 0 && r.interval < tick {
+		tick = r.interval
+	}
+
+	r.logger.InfoContext(
+		ctx, "Key rotator started",
+		slog.Duration("rotation_interval", r.interval),
+		slog.Duration("reload_interval", r.reloadInterval),
+		slog.Duration("publish_delay", r.publishDelay),
+	)
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.InfoContext(ctx, "Key rotator stopped")
+			return
+		case <-ticker.C:
+		}
+
+		r.tick(ctx)
+	}
+}
+
+// tick reloads the source and rotates the signing key if it is due. The next
+// key is generated one publish delay early, so it becomes active when the
+// current one reaches the rotation interval.
+func (r *Rotator) tick(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.reload(); err != nil {
+		r.logger.ErrorContext(ctx, "Failed to reload signing keys", slog.Any("error", err))
+		return
+	}
+
+	now := time.Now()
+	r.activateNext(now)
+
+	if r.interval > 0 && now.Sub(r.since) >= r.interval-r.publishDelay {
+		if err := r.rotate(); err != nil {
+			r.logger.ErrorContext(ctx, "Failed to rotate signing key", slog.Any("error", err))
+		}
+	}
+}
+
+func (r *Rotator) load() (Bundle, error) {
+	if r.source == nil {
+		return r.generate(time.Time{}, time.Now())
+	}
+	return r.source.Load()
+}
+
+// generate creates a key that becomes active at activeFrom and, for a Dir,
+// stores it there to succeed the key active since since before loading the
+// directory again. When another replica added that key first, its key is
+// loaded instead. Without a source the key is active right away.
+func (r *Rotator) generate(since, activeFrom time.Time) (Bundle, error) {
+	key, err := Generate(r.algorithm)
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	switch source := r.source.(type) {
+	case nil:
+		return Bundle{Active: key, Since: time.Now()}, nil
+	case Dir:
+		path, err := source.Rotate(key, since, activeFrom)
+		if errors.Is(err, ErrKeyAdded) {
+			r.logger.Info("Signing key was added by another process", slog.Any("source", source))
+			return source.Load()
+		}
+		if err != nil {
+			return Bundle{}, err
+		}
+		r.logger.Info(
+			"Generated signing key",
+			slog.String("kid", key.id),
+			slog.String("path", path),
+			slog.Time("active_from", activeFrom),
+		)
+		return source.Load()
+	default:
+		return Bundle{}, ErrRotationUnsupported
+	}
+}
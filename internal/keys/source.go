@@ -0,0 +1,277 @@
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoKeys = errors.New("no signing keys found")
+	// ErrKeyAdded is returned by Dir.Rotate when another process added the
+	// next key first.
+	ErrKeyAdded = errors.New("a newer signing key was added meanwhile")
+)
+
+// Bundle is the contents of a key source: the key to sign with and the keys
+// that are only published for verification.
+type Bundle struct {
+	Active Key
+	// Pending keys are published ahead of becoming active, so verifiers that
+	// cache the published keys know them before any token is signed with
+	// them.
+	Pending []Key
+	Retired []Key
+	// Since is when the active key was created.
+	Since time.Time
+}
+
+// Source loads signing keys, typically from a mounted secret.
+type Source interface {
+	Load() (Bundle, error)
+}
+
+// File is a single PEM private key. It cannot be rotated in place; rotate by
+// replacing the file and reloading.
+type File string
+
+func (f File) Load() (Bundle, error) {
+	key, info, err := readKey(string(f))
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	return Bundle{Active: key, Since: info.ModTime()}, nil
+}
+
+// Dir is a directory of PEM private keys, one per *.pem file. Keys written by
+// Add are named after the time they become active and are published as
+// pending until then; other files are due as soon as they are written. Of the
+// due keys, the one whose file name sorts last is active and every other key
+// is retired but still published.
+type Dir string
+
+// activationLayout is the time format file names written by Add start with.
+const activationLayout = "20060102T150405Z"
+
+func (d Dir) Load() (Bundle, error) {
+	entries, err := d.Entries(time.Now())
+	if err != nil {
+		return Bundle{}, err
+	}
+	if len(entries) == 0 {
+		return Bundle{}, fmt.Errorf("%w in %s", ErrNoKeys, d)
+	}
+
+	var bundle Bundle
+	for _, entry := range entries {
+		switch entry.Status {
+		case StatusActive:
+			bundle.Active = entry.Key
+			bundle.Since = entry.ActiveFrom
+		case StatusPending:
+			bundle.Pending = append(bundle.Pending, entry.Key)
+		default:
+			bundle.Retired = append(bundle.Retired, entry.Key)
+		}
+	}
+
+	return bundle, nil
+}
+
+// Status is the role of a key in a Dir.
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusActive  Status = "active"
+	StatusRetired Status = "retired"
+)
+
+// DirEntry is one key file of a Dir.
+type DirEntry struct {
+	Path    string
+	Key     Key
+	ModTime time.Time
+	// ActiveFrom is when the key becomes due.
+	ActiveFrom time.Time
+	Status     Status
+}
+
+// Entries returns the keys of the directory in file name order along with
+// their status at now.
+func (d Dir) Entries(now time.Time) ([]DirEntry, error) {
+	paths, err := filepath.Glob(filepath.Join(string(d), "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(paths)
+
+	entries := make([]DirEntry, 0, len(paths))
+	active := -1
+	for i, path := range paths {
+		key, info, err := readKey(path)
+		if err != nil {
+			return nil, err
+		}
+
+		activeFrom, err := time.Parse(activationLayout, strings.SplitN(filepath.Base(path), "-", 2)[0])
+		if err != nil {
+			activeFrom = info.ModTime()
+		}
+		if !activeFrom.After(now) {
+			active = i
+		}
+
+		entries = append(entries, DirEntry{Path: path, Key: key, ModTime: info.ModTime(), ActiveFrom: activeFrom})
+	}
+
+	// With no key due yet, the first one is activated early rather than
+	// leaving nothing to sign with.
+	if active == -1 {
+		active = 0
+	}
+	for i := range entries {
+		switch {
+		case i < active:
+			entries[i].Status = StatusRetired
+		case i == active:
+			entries[i].Status = StatusActive
+		default:
+			entries[i].Status = StatusPending
+		}
+	}
+
+	return entries, nil
+}
+
+// Prune deletes retired keys that were superseded more than retention ago and
+// returns the removed paths. A key is superseded when the key after it became
+// active, so retention should be at least the access token lifetime.
+func (d Dir) Prune(retention time.Duration, now time.Time) ([]string, error) {
+	entries, err := d.Entries(now)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for i := 0; i < len(entries)-1 && entries[i].Status == StatusRetired; i++ {
+		if now.Sub(entries[i+1].ActiveFrom) < retention {
+			continue
+		}
+		if err := os.Remove(entries[i].Path); err != nil {
+			return removed, fmt.Errorf("failed to remove signing key: %w", err)
+		}
+		removed = append(removed, entries[i].Path)
+	}
+
+	return removed, nil
+}
+
+// Add writes key to the directory to become active at activeFrom and returns
+// the file path. The file is named after that time, so keys sort in the order
+// they become active.
+func (d Dir) Add(key Key, activeFrom time.Time) (string, error) {
+	data, err := key.MarshalPEM()
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s-%s.pem", activeFrom.UTC().Format(activationLayout), key.id[:8])
+	path := filepath.Join(string(d), name)
+
+	// Write under a name Load ignores and rename, so a concurrent reload never
+	// sees a partially written key.
+	tmp := strings.TrimSuffix(path, ".pem") + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write signing key: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return "", fmt.Errorf("failed to write signing key: %w", err)
+	}
+
+	return path, nil
+}
+
+// Rotate adds key like Add to succeed the keys that became active up to since,
+// the zero time for an empty directory. It returns ErrKeyAdded when the
+// directory already holds a key that becomes active later, so replicas
+// rotating at the same time add a single key between them.
+func (d Dir) Rotate(key Key, since, activeFrom time.Time) (string, error) {
+	unlock, err := d.lock()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	entries, err := d.Entries(time.Now())
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if entry.ActiveFrom.After(since) {
+			return "", ErrKeyAdded
+		}
+	}
+
+	return d.Add(key, activeFrom)
+}
+
+// Bounds on waiting for the lock file of a Dir. A lock older than
+// staleLockAge was left behind by a process that died while rotating.
+const (
+	lockAttempts   = 50
+	lockRetryDelay = 100 * time.Millisecond
+	staleLockAge   = time.Minute
+	lockFileName   = ".rotate.lock"
+)
+
+// lock creates the lock file of the directory, waiting for another process
+// holding it, and returns the function removing it again.
+func (d Dir) lock() (func(), error) {
+	path := filepath.Join(string(d), lockFileName)
+
+	for range lockAttempts {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			_ = file.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to lock key directory: %w", err)
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(path)
+			continue
+		}
+		time.Sleep(lockRetryDelay)
+	}
+
+	return nil, fmt.Errorf("failed to lock key directory: %s is held by another process", path)
+}
+
+func readKey(path string) (Key, os.FileInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Key{}, nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	key, err := ParsePEM(data)
+	if err != nil {
+		return Key{}, nil, fmt.Errorf("failed to load signing key %s: %w", path, err)
+	}
+
+	return key, info, nil
+}
@@ -0,0 +1,64 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// FileMailer drops every mail into a directory as an .eml file instead of
+// delivering it, for development and CI where tests read the files back.
+type FileMailer struct {
+	dir  string
+	from *netmail.Address
+}
+
+var _ ports.Mailer = (*FileMailer)(nil)
+
+func NewFileMailer(dir string, from string) (*FileMailer, error) {
+	address, err := parseFrom(from)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	return &FileMailer{
+		dir:  dir,
+		from: address,
+	}, nil
+}
+
+func (m FileMailer) Send(_ context.Context, mail entities.Mail) error {
+	now := time.Now()
+	msg, err := compose(m.from, mail, now)
+	if err != nil {
+		return err
+	}
+
+	id, _, _ := strings.Cut(msg.id, "@")
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000Z"), id[:8])
+	path := filepath.Join(m.dir, name)
+
+	// Write under another name and rename, so readers polling the directory
+	// never see a partially written mail.
+	tmp := strings.TrimSuffix(path, ".eml") + ".tmp"
+	if err := os.WriteFile(tmp, msg.data, 0o644); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// message is a mail encoded as an RFC 5322 message, ready to be handed to an
+// SMTP server or written to an .eml file.
+type message struct {
+	id   string
+	from *netmail.Address
+	data []byte
+}
+
+// compose encodes mail with UTF-8 quoted-printable bodies, wrapping them in a
+// multipart/alternative body when mail has an HTML variant.
+func compose(from *netmail.Address, mail entities.Mail, now time.Time) (message, error) {
+	id, err := messageId(from)
+	if err != nil {
+		return message{}, err
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+
+	header("From", from.String())
+	header("To", string(mail.To))
+	header("Subject", mime.QEncoding.Encode("utf-8", mail.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", "<"+id+">")
+	header("MIME-Version", "1.0")
+
+	if mail.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, mail.Text); err != nil {
+			return message{}, err
+		}
+		return message{id: id, from: from, data: buf.Bytes()}, nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", mail.Text},
+		{"text/html; charset=utf-8", mail.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return message{}, fmt.Errorf("failed to compose mail: %w", err)
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return message{}, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return message{}, fmt.Errorf("failed to compose mail: %w", err)
+	}
+
+	return message{id: id, from: from, data: buf.Bytes()}, nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return fmt.Errorf("failed to compose mail: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return fmt.Errorf("failed to compose mail: %w", err)
+	}
+	return nil
+}
+
+// messageId returns a random Message-ID in the sender's domain.
+func messageId(from *netmail.Address) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate message id: %w", err)
+	}
+
+	domain := "localhost"
+	if _, host, ok := strings.Cut(from.Address, "@"); ok && host != "" {
+		domain = host
+	}
+	return hex.EncodeToString(b) + "@" + domain, nil
+}
+
+func parseFrom(from string) (*netmail.Address, error) {
+	address, err := netmail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", from, err)
+	}
+	return address, nil
+}
@@ -0,0 +1,134 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+const (
+	SMTPSecuritySTARTTLS = "starttls"
+	SMTPSecurityTLS      = "tls"
+	SMTPSecurityNone     = "none"
+)
+
+// smtpTimeout bounds a delivery whose context has no deadline.
+const smtpTimeout = 30 * time.Second
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// Security is SMTPSecuritySTARTTLS to require upgrading the connection,
+	// SMTPSecurityTLS for implicit TLS or SMTPSecurityNone for plain text,
+	// which only suits local sinks.
+	Security string
+	From     string
+}
+
+// SMTPMailer delivers mail through an SMTP relay, opening a connection for
+// every message.
+type SMTPMailer struct {
+	config SMTPConfig
+	from   *netmail.Address
+}
+
+var _ ports.Mailer = (*SMTPMailer)(nil)
+
+func NewSMTPMailer(config SMTPConfig) (*SMTPMailer, error) {
+	from, err := parseFrom(config.From)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SMTPMailer{
+		config: config,
+		from:   from,
+	}, nil
+}
+
+func (m SMTPMailer) Send(ctx context.Context, mail entities.Mail) error {
+	msg, err := compose(m.from, mail, time.Now())
+	if err != nil {
+		return err
+	}
+
+	conn, err := m.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	defer client.Close()
+
+	if m.config.Security == SMTPSecuritySTARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: m.config.Host}); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+
+	if m.config.Username != "" {
+		auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("failed to authenticate to smtp server: %w", err)
+		}
+	}
+
+	if err := client.Mail(msg.from.Address); err != nil {
+		return fmt.Errorf("smtp server rejected sender: %w", err)
+	}
+	if err := client.Rcpt(string(mail.To)); err != nil {
+		return fmt.Errorf("smtp server rejected recipient: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	if _, err := w.Write(msg.data); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (m SMTPMailer) dial(ctx context.Context) (net.Conn, error) {
+	address := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+
+	if m.config.Security == SMTPSecurityTLS {
+		dialer := tls.Dialer{Config: &tls.Config{ServerName: m.config.Host}}
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", address)
+}
@@ -0,0 +1,170 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+//go:embed templates
+var builtin embed.FS
+
+// Templates renders mail from a tree of per-locale templates laid out as
+// <locale>/<name>.txt and, optionally, <locale>/<name>.html. The text
+// template must define a "subject" block.
+type Templates struct {
+	defaultLocale string
+
+	// text and html are keyed by "<locale>/<name>".
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+var _ ports.MailRenderer = (*Templates)(nil)
+
+var templateFuncs = map[string]any{
+	"duration": formatDuration,
+}
+
+// BuiltinTemplates returns the templates shipped with the service.
+func BuiltinTemplates() fs.FS {
+	fsys, err := fs.Sub(builtin, "templates")
+	if err != nil {
+		panic(err)
+	}
+	return fsys
+}
+
+// NewTemplates parses every template in fsys. Each template has to exist in
+// the default locale, which is what other locales fall back to.
+func NewTemplates(fsys fs.FS, defaultLocale string) (*Templates, error) {
+	t := &Templates{
+		defaultLocale: normalizeLocale(defaultLocale),
+		text:          make(map[string]*texttemplate.Template),
+		html:          make(map[string]*htmltemplate.Template),
+	}
+
+	files, err := fs.Glob(fsys, "*/*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mail templates: %w", err)
+	}
+
+	for _, file := range files {
+		locale, base := path.Split(file)
+		key := normalizeLocale(strings.TrimSuffix(locale, "/")) + "/" + strings.TrimSuffix(base, path.Ext(base))
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mail template %s: %w", file, err)
+		}
+
+		switch path.Ext(base) {
+		case ".txt":
+			tmpl, err := texttemplate.New(base).Funcs(templateFuncs).Parse(string(data))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse mail template %s: %w", file, err)
+			}
+			if tmpl.Lookup("subject") == nil {
+				return nil, fmt.Errorf("mail template %s does not define a subject", file)
+			}
+			t.text[key] = tmpl
+		case ".html":
+			tmpl, err := htmltemplate.New(base).Funcs(templateFuncs).Parse(string(data))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse mail template %s: %w", file, err)
+			}
+			t.html[key] = tmpl
+		}
+	}
+
+	for key := range t.html {
+		if _, ok := t.text[key]; !ok {
+			return nil, fmt.Errorf("mail template %s.html has no text variant", key)
+		}
+	}
+	for key := range t.text {
+		_, name := path.Split(key)
+		if _, ok := t.text[t.defaultLocale+"/"+name]; !ok {
+			return nil, fmt.Errorf("mail template %s is missing from the default locale %q", name, t.defaultLocale)
+		}
+	}
+
+	return t, nil
+}
+
+// Render implements ports.MailRenderer. Locales are matched exactly first,
+// then by language alone, so "pt-BR" falls back to "pt" and then to the
+// default locale.
+func (t *Templates) Render(name string, locale string, data any) (entities.Mail, error) {
+	for _, candidate := range t.candidates(locale) {
+		key := candidate + "/" + name
+		text, ok := t.text[key]
+		if !ok {
+			continue
+		}
+
+		var subject, body bytes.Buffer
+		if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+			return entities.Mail{}, fmt.Errorf("failed to render mail template %s: %w", key, err)
+		}
+		if err := text.Execute(&body, data); err != nil {
+			return entities.Mail{}, fmt.Errorf("failed to render mail template %s: %w", key, err)
+		}
+
+		mail := entities.Mail{
+			Subject: strings.TrimSpace(subject.String()),
+			Text:    strings.TrimLeft(body.String(), "\n"),
+		}
+
+		if html, ok := t.html[key]; ok {
+			var body bytes.Buffer
+			if err := html.Execute(&body, data); err != nil {
+				return entities.Mail{}, fmt.Errorf("failed to render mail template %s: %w", key, err)
+			}
+			mail.HTML = body.String()
+		}
+
+		return mail, nil
+	}
+
+	return entities.Mail{}, fmt.Errorf("unknown mail template %q", name)
+}
+
+func (t *Templates) candidates(locale string) []string {
+	locale = normalizeLocale(locale)
+	if locale == "" {
+		return []string{t.defaultLocale}
+	}
+
+	candidates := []string{locale}
+	if language, _, ok := strings.Cut(locale, "-"); ok {
+		candidates = append(candidates, language)
+	}
+	return append(candidates, t.defaultLocale)
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// formatDuration prints d without the zero units time.Duration.String
+// appends, e.g. "24h" rather than "24h0m0s".
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
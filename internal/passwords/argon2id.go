@@ -0,0 +1,127 @@
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const AlgorithmArgon2id = "argon2id"
+
+// Bounds on the memory, in KiB, and the iterations a stored hash may ask for,
+// so a corrupt or hostile hash cannot exhaust the host.
+const (
+	maxArgon2Memory     = 1024 * 1024
+	maxArgon2Iterations = 16
+)
+
+// Argon2id hashes passwords with argon2id, encoded as
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>.
+type Argon2id struct {
+	// Memory is in KiB.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2id follows the OWASP recommendation of 19 MiB of memory and
+// two iterations.
+var DefaultArgon2id = Argon2id{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func (a Argon2id) id() string {
+	return AlgorithmArgon2id
+}
+
+func (a Argon2id) hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+
+	return phc{
+		id:      AlgorithmArgon2id,
+		version: argon2.Version,
+		params: []phcParam{
+			{name: "m", value: strconv.FormatUint(uint64(a.Memory), 10)},
+			{name: "t", value: strconv.FormatUint(uint64(a.Iterations), 10)},
+			{name: "p", value: strconv.FormatUint(uint64(a.Parallelism), 10)},
+		},
+		salt: salt,
+		hash: key,
+	}.String(), nil
+}
+
+func (a Argon2id) verify(encoded string, password string) (bool, bool, error) {
+	h, err := parseArgon2id(encoded)
+	if err != nil {
+		return false, false, err
+	}
+
+	key := argon2.IDKey(
+		[]byte(password), h.salt, uint32(h.iterations), uint32(h.memory), uint8(h.parallelism), uint32(len(h.hash)),
+	)
+	if subtle.ConstantTimeCompare(key, h.hash) != 1 {
+		return false, false, nil
+	}
+
+	outdated := uint32(h.memory) < a.Memory ||
+		uint32(h.iterations) < a.Iterations ||
+		uint8(h.parallelism) != a.Parallelism ||
+		uint32(len(h.salt)) < a.SaltLength ||
+		uint32(len(h.hash)) < a.KeyLength
+	return true, outdated, nil
+}
+
+func (a Argon2id) check(encoded string) error {
+	_, err := parseArgon2id(encoded)
+	return err
+}
+
+type argon2idHash struct {
+	phc
+	memory      uint64
+	iterations  uint64
+	parallelism uint64
+}
+
+func parseArgon2id(encoded string) (argon2idHash, error) {
+	h, err := parsePHC(encoded)
+	if err != nil {
+		return argon2idHash{}, err
+	}
+	if h.version != argon2.Version {
+		return argon2idHash{}, fmt.Errorf("%w: unsupported argon2 version %d", ErrMalformedHash, h.version)
+	}
+
+	memory, err := h.param("m")
+	if err != nil {
+		return argon2idHash{}, err
+	}
+	iterations, err := h.param("t")
+	if err != nil {
+		return argon2idHash{}, err
+	}
+	parallelism, err := h.param("p")
+	if err != nil {
+		return argon2idHash{}, err
+	}
+	if parallelism == 0 || parallelism > 255 || iterations == 0 || iterations > maxArgon2Iterations ||
+		memory > maxArgon2Memory || len(h.salt) == 0 || len(h.hash) == 0 {
+		return argon2idHash{}, ErrMalformedHash
+	}
+
+	return argon2idHash{phc: h, memory: memory, iterations: iterations, parallelism: parallelism}, nil
+}
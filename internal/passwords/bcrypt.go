@@ -0,0 +1,94 @@
+package passwords
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+const AlgorithmBcrypt = "bcrypt"
+
+// MaxBcryptLength is the number of bytes bcrypt looks at; anything after it
+// would be silently ignored.
+const MaxBcryptLength = 72
+
+// Bcrypt hashes passwords with bcrypt in its own modular crypt format,
+// $2a$<cost>$<salt and hash>, which is what the PHC format prescribes for it.
+type Bcrypt struct {
+	Cost int
+}
+
+var DefaultBcrypt = Bcrypt{Cost: 12}
+
+// maxBcryptCost bounds the cost a stored hash may ask for. Every step doubles
+// the work, and bcrypt itself accepts up to 31, which takes days to verify.
+const maxBcryptCost = 16
+
+func (b Bcrypt) id() string {
+	return AlgorithmBcrypt
+}
+
+func (b Bcrypt) hash(password string) (string, error) {
+	if len(password) > MaxBcryptLength {
+		return "", &entities.ValidationError{
+			Field:   "password",
+			Code:    entities.CodeTooLong,
+			Message: fmt.Sprintf("should be at most %d bytes long", MaxBcryptLength),
+			Params:  entities.ValidationParams{"max": MaxBcryptLength},
+		}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate hash from password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// verify refuses passwords longer than bcrypt can handle instead of
+// comparing their truncated prefix.
+func (b Bcrypt) verify(encoded string, password string) (bool, bool, error) {
+	if len(password) > MaxBcryptLength {
+		return false, false, nil
+	}
+
+	cost, err := parseBcryptCost(encoded)
+	if err != nil {
+		return false, false, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, fmt.Errorf("%w: %w", ErrMalformedHash, err)
+	}
+	return true, cost < b.Cost, nil
+}
+
+func (b Bcrypt) check(encoded string) error {
+	_, err := parseBcryptCost(encoded)
+	return err
+}
+
+func parseBcryptCost(encoded string) (int, error) {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrMalformedHash, err)
+	}
+	if cost > maxBcryptCost {
+		return 0, fmt.Errorf("%w: bcrypt cost %d exceeds %d", ErrMalformedHash, cost, maxBcryptCost)
+	}
+	return cost, nil
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
@@ -0,0 +1,106 @@
+// Package passwords hashes and verifies user passwords. New hashes use the
+// configured algorithm; hashes made with any supported algorithm, including
+// legacy ones imported from other systems, keep verifying and are flagged for
+// rehashing when they are outdated.
+package passwords
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+var ErrUnsupportedHash = errors.New("unsupported password hash")
+
+type scheme interface {
+	id() string
+	// verify reports whether password matches encoded and whether encoded
+	// uses weaker parameters than the scheme is configured with.
+	verify(encoded string, password string) (ok bool, outdated bool, err error)
+	// check reports an error for an encoded hash verify would reject as
+	// malformed, without computing it.
+	check(encoded string) error
+}
+
+// hashingScheme is a scheme new hashes can be made with.
+type hashingScheme interface {
+	scheme
+	hash(password string) (string, error)
+}
+
+type Hasher struct {
+	preferred hashingScheme
+	argon2id  Argon2id
+	bcrypt    Bcrypt
+}
+
+var _ entities.PasswordHasher = (*Hasher)(nil)
+
+// New returns a Hasher producing algorithm hashes with the given parameters.
+func New(algorithm string, argon2id Argon2id, bcrypt Bcrypt) (*Hasher, error) {
+	h := &Hasher{
+		argon2id: argon2id,
+		bcrypt:   bcrypt,
+	}
+
+	switch algorithm {
+	case AlgorithmArgon2id:
+		h.preferred = argon2id
+	case AlgorithmBcrypt:
+		h.preferred = bcrypt
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q", algorithm)
+	}
+
+	return h, nil
+}
+
+func (h *Hasher) Hash(password string) (entities.Password, error) {
+	encoded, err := h.preferred.hash(password)
+	if err != nil {
+		return "", err
+	}
+	return entities.RawPassword(encoded), nil
+}
+
+// Verify implements entities.PasswordHasher. Hashes of another algorithm than
+// the preferred one always need rehashing.
+func (h *Hasher) Verify(hash entities.Password, password string) (bool, bool, error) {
+	s, err := h.scheme(string(hash))
+	if err != nil {
+		return false, false, err
+	}
+
+	ok, outdated, err := s.verify(string(hash), password)
+	if err != nil || !ok {
+		return false, false, err
+	}
+
+	return true, outdated || s.id() != h.preferred.id(), nil
+}
+
+// Supported reports whether hash is in a format Verify understands, is
+// well-formed and asks for no more work than Verify is willing to do.
+func (h *Hasher) Supported(hash entities.Password) bool {
+	s, err := h.scheme(string(hash))
+	return err == nil && s.check(string(hash)) == nil
+}
+
+func (h *Hasher) scheme(encoded string) (scheme, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$"+AlgorithmArgon2id+"$"):
+		return h.argon2id, nil
+	case isBcrypt(encoded):
+		return h.bcrypt, nil
+	case strings.HasPrefix(encoded, "$"+AlgorithmPBKDF2SHA256+"$"):
+		return pbkdf2SHA256{}, nil
+	case strings.HasPrefix(encoded, "$"+AlgorithmScrypt+"$"):
+		return scryptScheme{}, nil
+	case strings.HasPrefix(encoded, sshaPrefix):
+		return ssha{}, nil
+	default:
+		return nil, ErrUnsupportedHash
+	}
+}
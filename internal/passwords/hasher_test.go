@@ -0,0 +1,115 @@
+package passwords_test
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/maxdikun/users-api/internal/entities"
+	"github.com/maxdikun/users-api/internal/passwords"
+)
+
+// fastArgon2id keeps the tests quick; its hashes are outdated against
+// passwords.DefaultArgon2id.
+var fastArgon2id = passwords.Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func newHasher(t *testing.T, algorithm string) *passwords.Hasher {
+	t.Helper()
+
+	h, err := passwords.New(algorithm, fastArgon2id, passwords.Bcrypt{Cost: bcrypt.MinCost})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return h
+}
+
+func TestHasherRoundTrip(t *testing.T) {
+	for _, algorithm := range []string{passwords.AlgorithmArgon2id, passwords.AlgorithmBcrypt} {
+		t.Run(algorithm, func(t *testing.T) {
+			h := newHasher(t, algorithm)
+
+			hash, err := h.Hash("correct horse")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			if !h.Supported(hash) {
+				t.Errorf("Supported(%q) = false", hash)
+			}
+
+			ok, outdated, err := h.Verify(hash, "correct horse")
+			if err != nil || !ok || outdated {
+				t.Errorf("Verify() = %v, %v, %v, want true, false, nil", ok, outdated, err)
+			}
+			ok, _, err = h.Verify(hash, "wrong horse")
+			if err != nil || ok {
+				t.Errorf("Verify() of wrong password = %v, %v, want false, nil", ok, err)
+			}
+		})
+	}
+}
+
+func TestHasherFlagsOutdatedHashes(t *testing.T) {
+	argon2, bcryptHasher := newHasher(t, passwords.AlgorithmArgon2id), newHasher(t, passwords.AlgorithmBcrypt)
+	stronger, err := passwords.New(
+		passwords.AlgorithmArgon2id,
+		passwords.Argon2id{Memory: 128, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+		passwords.Bcrypt{Cost: bcrypt.MinCost},
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	weak, err := argon2.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if _, outdated, _ := stronger.Verify(weak, "correct horse"); !outdated {
+		t.Error("Verify() of hash with weaker parameters is not outdated")
+	}
+	if _, outdated, _ := bcryptHasher.Verify(weak, "correct horse"); !outdated {
+		t.Error("Verify() of hash of another algorithm is not outdated")
+	}
+}
+
+func TestHasherRejectsExcessiveWork(t *testing.T) {
+	h := newHasher(t, passwords.AlgorithmArgon2id)
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to generate bcrypt hash: %v", err)
+	}
+	withCost := func(cost string) entities.Password {
+		return entities.RawPassword(strings.Replace(string(bcryptHash), "$04$", "$"+cost+"$", 1))
+	}
+	const argon2Salt = "$c2FsdHNhbHRzYWx0c2FsdA$ZGlnZXN0ZGlnZXN0ZGlnZXN0ZGlnZXN0ZGlnZXN0MTI"
+
+	tests := []struct {
+		name string
+		hash entities.Password
+		want bool
+	}{
+		{"argon2id at the iteration cap", entities.RawPassword("$argon2id$v=19$m=64,t=16,p=1" + argon2Salt), true},
+		{"argon2id above the iteration cap", entities.RawPassword("$argon2id$v=19$m=64,t=17,p=1" + argon2Salt), false},
+		{"argon2id with maximal iterations", entities.RawPassword("$argon2id$v=19$m=64,t=4294967295,p=1" + argon2Salt), false},
+		{"argon2id above the memory cap", entities.RawPassword("$argon2id$v=19$m=1048577,t=1,p=1" + argon2Salt), false},
+		{"bcrypt at the cost cap", withCost("16"), true},
+		{"bcrypt above the cost cap", withCost("17"), false},
+		{"bcrypt with maximal cost", withCost("31"), false},
+		{"scrypt above the parallelism cap", entities.RawPassword("$scrypt$ln=4,r=8,p=17$c2FsdA$ZGlnZXN0"), false},
+		{"unknown algorithm", entities.RawPassword("$md5$c2FsdA$ZGlnZXN0"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.Supported(tt.hash); got != tt.want {
+				t.Errorf("Supported(%q) = %v, want %v", tt.hash, got, tt.want)
+			}
+			if !tt.want {
+				if _, _, err := h.Verify(tt.hash, "correct horse"); err == nil {
+					t.Errorf("Verify(%q) error = nil", tt.hash)
+				}
+			}
+		})
+	}
+}
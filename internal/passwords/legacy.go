@@ -0,0 +1,172 @@
+package passwords
+
+import (
+	"bytes"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Legacy algorithms are only verified, never produced: a hash imported from
+// another system keeps working until its user logs in and it is replaced.
+const (
+	AlgorithmPBKDF2SHA256 = "pbkdf2-sha256"
+	AlgorithmScrypt       = "scrypt"
+	AlgorithmSSHA         = "ssha"
+)
+
+// Bounds on the work a stored legacy hash may ask for.
+const (
+	maxPBKDF2Iterations  = 10_000_000
+	maxScryptMemory      = 1 << 30
+	maxScryptParallelism = 16
+)
+
+// pbkdf2SHA256 verifies $pbkdf2-sha256$i=<iterations>$<salt>$<hash>, and
+// passlib's $pbkdf2-sha256$<iterations>$<salt>$<hash> with its adapted base64,
+// which uses "." instead of "+".
+type pbkdf2SHA256 struct{}
+
+func (pbkdf2SHA256) id() string {
+	return AlgorithmPBKDF2SHA256
+}
+
+func (pbkdf2SHA256) verify(encoded string, password string) (bool, bool, error) {
+	h, iterations, err := parsePBKDF2SHA256(encoded)
+	if err != nil {
+		return false, false, err
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, h.salt, int(iterations), len(h.hash))
+	if err != nil {
+		return false, false, fmt.Errorf("%w: %w", ErrMalformedHash, err)
+	}
+	return subtle.ConstantTimeCompare(key, h.hash) == 1, true, nil
+}
+
+func (pbkdf2SHA256) check(encoded string) error {
+	_, _, err := parsePBKDF2SHA256(encoded)
+	return err
+}
+
+func parsePBKDF2SHA256(encoded string) (phc, uint64, error) {
+	fields := strings.Split(strings.ReplaceAll(encoded, ".", "+"), "$")
+	if len(fields) == 5 && !strings.Contains(fields[2], "=") {
+		fields[2] = "i=" + fields[2]
+	}
+
+	h, err := parsePHC(strings.Join(fields, "$"))
+	if err != nil {
+		return phc{}, 0, err
+	}
+
+	iterations, err := h.param("i")
+	if err != nil {
+		return phc{}, 0, err
+	}
+	if iterations == 0 || iterations > maxPBKDF2Iterations || len(h.salt) == 0 || len(h.hash) == 0 {
+		return phc{}, 0, ErrMalformedHash
+	}
+
+	return h, iterations, nil
+}
+
+// scryptScheme verifies $scrypt$ln=<log2 N>,r=<r>,p=<p>$<salt>$<hash>, as
+// written by passlib.
+type scryptScheme struct{}
+
+func (scryptScheme) id() string {
+	return AlgorithmScrypt
+}
+
+func (scryptScheme) verify(encoded string, password string) (bool, bool, error) {
+	h, err := parseScrypt(encoded)
+	if err != nil {
+		return false, false, err
+	}
+
+	key, err := scrypt.Key([]byte(password), h.salt, 1<<h.ln, int(h.r), int(h.p), len(h.hash))
+	if err != nil {
+		return false, false, fmt.Errorf("%w: %w", ErrMalformedHash, err)
+	}
+	return subtle.ConstantTimeCompare(key, h.hash) == 1, true, nil
+}
+
+func (scryptScheme) check(encoded string) error {
+	_, err := parseScrypt(encoded)
+	return err
+}
+
+type scryptHash struct {
+	phc
+	ln uint64
+	r  uint64
+	p  uint64
+}
+
+func parseScrypt(encoded string) (scryptHash, error) {
+	h, err := parsePHC(encoded)
+	if err != nil {
+		return scryptHash{}, err
+	}
+
+	ln, err := h.param("ln")
+	if err != nil {
+		return scryptHash{}, err
+	}
+	r, err := h.param("r")
+	if err != nil {
+		return scryptHash{}, err
+	}
+	p, err := h.param("p")
+	if err != nil {
+		return scryptHash{}, err
+	}
+	// p runs the memory-hard function that many times over, so it bounds the
+	// CPU time of a hash much like N and r bound its memory.
+	if ln == 0 || ln > 30 || r == 0 || r > 1<<16 || p == 0 || p > maxScryptParallelism ||
+		128*r<<ln > maxScryptMemory || len(h.salt) == 0 || len(h.hash) == 0 {
+		return scryptHash{}, ErrMalformedHash
+	}
+
+	return scryptHash{phc: h, ln: ln, r: r, p: p}, nil
+}
+
+// ssha verifies salted SHA-1 in the LDAP {SSHA} format: base64 of
+// SHA1(password || salt) followed by the salt.
+type ssha struct{}
+
+const sshaPrefix = "{SSHA}"
+
+func (ssha) id() string {
+	return AlgorithmSSHA
+}
+
+func (ssha) verify(encoded string, password string) (bool, bool, error) {
+	digest, salt, err := parseSSHA(encoded)
+	if err != nil {
+		return false, false, err
+	}
+
+	sum := sha1.Sum(bytes.Join([][]byte{[]byte(password), salt}, nil))
+	return subtle.ConstantTimeCompare(sum[:], digest) == 1, true, nil
+}
+
+func (ssha) check(encoded string) error {
+	_, _, err := parseSSHA(encoded)
+	return err
+}
+
+func parseSSHA(encoded string) ([]byte, []byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encoded, sshaPrefix))
+	if err != nil || len(decoded) <= sha1.Size {
+		return nil, nil, ErrMalformedHash
+	}
+	return decoded[:sha1.Size], decoded[sha1.Size:], nil
+}
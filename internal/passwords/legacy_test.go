@@ -0,0 +1,60 @@
+package passwords_test
+
+import (
+	"testing"
+
+	"github.com/maxdikun/users-api/internal/entities"
+	"github.com/maxdikun/users-api/internal/passwords"
+)
+
+// TestLegacyKnownAnswers checks the legacy verifiers against hashes computed
+// independently, with Python's hashlib, for the password "password".
+func TestLegacyKnownAnswers(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{
+			// passlib's format: bare iteration count and its adapted base64,
+			// with "." in place of "+" in both the salt and the hash.
+			name: "passlib pbkdf2-sha256",
+			hash: "$pbkdf2-sha256$29000$....X.zrZv/IbzjZUnhsbQ$A74vx9HpVJ.WUk7vJ3qSooKtqNTrjcDLou6RTjoM9Is",
+		},
+		{
+			name: "PHC pbkdf2-sha256",
+			hash: "$pbkdf2-sha256$i=1000$c2FsdHlzYWx0MTIzNDU2IQ$JlOW+ECqHZ/nF9Ra6rB3nIOQbsPNdbf6K4q9IONRZlo",
+		},
+		{
+			// From the passlib documentation.
+			name: "passlib scrypt",
+			hash: "$scrypt$ln=16,r=8,p=1$aM15713r3Xsvxbi31lqr1Q$nFNh2CVHVjNldFVKDHDlm4CbdRSCdEBsjjJxD+iCs5E",
+		},
+		{
+			name: "ssha",
+			hash: "{SSHA}Of/yLrzPtvyBFmA0cVjJCFLLIuqPGgJ/",
+		},
+	}
+
+	h := newHasher(t, passwords.AlgorithmArgon2id)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash := entities.RawPassword(tt.hash)
+			if !h.Supported(hash) {
+				t.Fatalf("Supported(%q) = false", tt.hash)
+			}
+
+			ok, outdated, err := h.Verify(hash, "password")
+			if err != nil || !ok {
+				t.Fatalf("Verify() = %v, %v, want true, nil", ok, err)
+			}
+			if !outdated {
+				t.Error("Verify() of a legacy hash is not outdated")
+			}
+
+			ok, _, err = h.Verify(hash, "Password")
+			if err != nil || ok {
+				t.Errorf("Verify() of wrong password = %v, %v, want false, nil", ok, err)
+			}
+		})
+	}
+}
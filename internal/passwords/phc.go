@@ -0,0 +1,115 @@
+package passwords
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrMalformedHash = errors.New("malformed password hash")
+
+// phc is a hash in the PHC string format,
+//
+//	$<id>[$v=<version>][$<param>=<value>(,<param>=<value>)*][$<salt>[$<hash>]]
+//
+// with salt and hash in unpadded standard base64.
+type phc struct {
+	id      string
+	version int
+	params  []phcParam
+	salt    []byte
+	hash    []byte
+}
+
+type phcParam struct {
+	name  string
+	value string
+}
+
+func (h phc) String() string {
+	var b strings.Builder
+	b.WriteString("$" + h.id)
+	if h.version > 0 {
+		b.WriteString("$v=" + strconv.Itoa(h.version))
+	}
+	if len(h.params) > 0 {
+		params := make([]string, 0, len(h.params))
+		for _, p := range h.params {
+			params = append(params, p.name+"="+p.value)
+		}
+		b.WriteString("$" + strings.Join(params, ","))
+	}
+	if h.salt != nil {
+		b.WriteString("$" + base64.RawStdEncoding.EncodeToString(h.salt))
+		if h.hash != nil {
+			b.WriteString("$" + base64.RawStdEncoding.EncodeToString(h.hash))
+		}
+	}
+	return b.String()
+}
+
+func parsePHC(s string) (phc, error) {
+	fields := strings.Split(s, "$")
+	if len(fields) < 2 || fields[0] != "" || fields[1] == "" {
+		return phc{}, ErrMalformedHash
+	}
+
+	h := phc{id: fields[1]}
+	fields = fields[2:]
+
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "v=") {
+		version, err := strconv.Atoi(strings.TrimPrefix(fields[0], "v="))
+		if err != nil {
+			return phc{}, fmt.Errorf("%w: invalid version", ErrMalformedHash)
+		}
+		h.version = version
+		fields = fields[1:]
+	}
+
+	if len(fields) > 0 && strings.Contains(fields[0], "=") {
+		for _, pair := range strings.Split(fields[0], ",") {
+			name, value, ok := strings.Cut(pair, "=")
+			if !ok || name == "" {
+				return phc{}, fmt.Errorf("%w: invalid parameter %q", ErrMalformedHash, pair)
+			}
+			h.params = append(h.params, phcParam{name: name, value: value})
+		}
+		fields = fields[1:]
+	}
+
+	if len(fields) > 2 {
+		return phc{}, ErrMalformedHash
+	}
+	if len(fields) > 0 {
+		salt, err := base64.RawStdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return phc{}, fmt.Errorf("%w: invalid salt", ErrMalformedHash)
+		}
+		h.salt = salt
+	}
+	if len(fields) > 1 {
+		hash, err := base64.RawStdEncoding.DecodeString(fields[1])
+		if err != nil {
+			return phc{}, fmt.Errorf("%w: invalid hash", ErrMalformedHash)
+		}
+		h.hash = hash
+	}
+
+	return h, nil
+}
+
+// param returns the value of a numeric parameter.
+func (h phc) param(name string) (uint64, error) {
+	for _, p := range h.params {
+		if p.name == name {
+			value, err := strconv.ParseUint(p.value, 10, 32)
+			if err != nil {
+				return 0, fmt.Errorf("%w: invalid parameter %s", ErrMalformedHash, name)
+			}
+			return value, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: missing parameter %s", ErrMalformedHash, name)
+}
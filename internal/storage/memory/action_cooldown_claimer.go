@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type ActionCooldownClaimer struct {
+	db *Database
+}
+
+var _ ports.ActionCooldownClaimer = (*ActionCooldownClaimer)(nil)
+
+func NewActionCooldownClaimer(db *Database) *ActionCooldownClaimer {
+	return &ActionCooldownClaimer{
+		db: db,
+	}
+}
+
+func (a ActionCooldownClaimer) ClaimActionCooldown(
+	_ context.Context,
+	user uuid.UUID,
+	purpose entities.ActionPurpose,
+	at time.Time,
+	cooldown time.Duration,
+) (bool, error) {
+	a.db.mu.Lock()
+	defer a.db.mu.Unlock()
+
+	key := actionCooldownKey{user: user, purpose: purpose}
+	if claimed, ok := a.db.actionCooldowns[key]; ok && claimed.After(at.Add(-cooldown)) {
+		return false, nil
+	}
+
+	a.db.actionCooldowns[key] = at
+	return true, nil
+}
@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type ActionTokenAppender struct {
+	db *Database
+}
+
+var _ ports.ActionTokenAppender = (*ActionTokenAppender)(nil)
+
+func NewActionTokenAppender(db *Database) *ActionTokenAppender {
+	return &ActionTokenAppender{
+		db: db,
+	}
+}
+
+func (a ActionTokenAppender) AppendActionToken(_ context.Context, token entities.ActionToken) error {
+	a.db.mu.Lock()
+	defer a.db.mu.Unlock()
+
+	if _, ok := a.db.actionTokens[token.Id()]; ok {
+		return a.duplication("id")
+	}
+
+	for _, existing := range a.db.actionTokens {
+		if existing.TokenHash() == token.TokenHash() {
+			return a.duplication("token")
+		}
+	}
+
+	a.db.actionTokens[token.Id()] = token
+	return nil
+}
+
+func (a ActionTokenAppender) duplication(field string) error {
+	return &ports.DuplicationError{
+		Source: "memory.ActionTokenAppender",
+		Object: "action_token",
+		Field:  field,
+	}
+}
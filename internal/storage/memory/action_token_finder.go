@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// ActionTokenFinder implements both ActionTokenFinder and
+// ActionTokenConsumer, as they read the same tokens.
+type ActionTokenFinder struct {
+	db *Database
+}
+
+var (
+	_ ports.ActionTokenFinder   = (*ActionTokenFinder)(nil)
+	_ ports.ActionTokenConsumer = (*ActionTokenFinder)(nil)
+)
+
+func NewActionTokenFinder(db *Database) *ActionTokenFinder {
+	return &ActionTokenFinder{
+		db: db,
+	}
+}
+
+func (a ActionTokenFinder) FindLatestActionToken(
+	_ context.Context,
+	user uuid.UUID,
+	purpose entities.ActionPurpose,
+) (entities.ActionToken, error) {
+	a.db.mu.RLock()
+	defer a.db.mu.RUnlock()
+
+	var (
+		latest entities.ActionToken
+		found  bool
+	)
+	for _, token := range a.db.actionTokens {
+		if token.User() != user || token.Purpose() != purpose {
+			continue
+		}
+		if !found || token.CreatedAt().After(latest.CreatedAt()) {
+			latest, found = token, true
+		}
+	}
+
+	if !found {
+		return entities.ActionToken{}, &ports.NotFoundError{
+			Source: "memory.ActionTokenFinder",
+			Object: "action_token",
+			Field:  "user_id",
+		}
+	}
+
+	return latest, nil
+}
+
+func (a ActionTokenFinder) FindUsableActionToken(
+	_ context.Context,
+	purpose entities.ActionPurpose,
+	tokenHash string,
+	at time.Time,
+) (entities.ActionToken, error) {
+	a.db.mu.RLock()
+	defer a.db.mu.RUnlock()
+
+	for _, token := range a.db.actionTokens {
+		if token.TokenHash() != tokenHash || token.Purpose() != purpose {
+			continue
+		}
+		if token.UsedAt() != nil || !at.Before(token.ExpiresAt()) {
+			break
+		}
+		return token, nil
+	}
+
+	return entities.ActionToken{}, &ports.NotFoundError{
+		Source: "memory.ActionTokenFinder",
+		Object: "action_token",
+		Field:  "token",
+	}
+}
+
+func (a ActionTokenFinder) ConsumeActionToken(
+	_ context.Context,
+	purpose entities.ActionPurpose,
+	tokenHash string,
+	at time.Time,
+) (entities.ActionToken, error) {
+	a.db.mu.Lock()
+	defer a.db.mu.Unlock()
+
+	for id, token := range a.db.actionTokens {
+		if token.TokenHash() != tokenHash || token.Purpose() != purpose {
+			continue
+		}
+		if token.UsedAt() != nil || !at.Before(token.ExpiresAt()) {
+			break
+		}
+
+		consumed := entities.LoadActionToken(
+			token.Id(), token.User(), token.Purpose(), token.TokenHash(),
+			token.CreatedAt(), token.ExpiresAt(), &at,
+		)
+		a.db.actionTokens[id] = consumed
+		return consumed, nil
+	}
+
+	return entities.ActionToken{}, &ports.NotFoundError{
+		Source: "memory.ActionTokenFinder",
+		Object: "action_token",
+		Field:  "token",
+	}
+}
+
+func (a ActionTokenFinder) ConsumeUserActionTokens(
+	_ context.Context,
+	user uuid.UUID,
+	purpose entities.ActionPurpose,
+	at time.Time,
+) (int, error) {
+	a.db.mu.Lock()
+	defer a.db.mu.Unlock()
+
+	consumed := 0
+	for id, token := range a.db.actionTokens {
+		if token.User() != user || token.Purpose() != purpose || token.UsedAt() != nil {
+			continue
+		}
+
+		a.db.actionTokens[id] = entities.LoadActionToken(
+			token.Id(), token.User(), token.Purpose(), token.TokenHash(),
+			token.CreatedAt(), token.ExpiresAt(), &at,
+		)
+		consumed++
+	}
+
+	return consumed, nil
+}
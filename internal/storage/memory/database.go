@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// Database is an in-process replacement for the Postgres database. It is
+// shared by the memory adapters the same way a pool is shared by the
+// Postgres ones and is safe for concurrent use.
+type Database struct {
+	mu sync.RWMutex
+
+	users           map[uuid.UUID]entities.User
+	sessions        map[uuid.UUID]entities.Session
+	rotatedTokens   map[string]uuid.UUID
+	actionTokens    map[uuid.UUID]entities.ActionToken
+	actionCooldowns map[actionCooldownKey]time.Time
+}
+
+// actionCooldownKey identifies the cooldown of mailing a user for a purpose.
+type actionCooldownKey struct {
+	user    uuid.UUID
+	purpose entities.ActionPurpose
+}
+
+func NewDatabase() *Database {
+	return &Database{
+		users:           make(map[uuid.UUID]entities.User),
+		sessions:        make(map[uuid.UUID]entities.Session),
+		rotatedTokens:   make(map[string]uuid.UUID),
+		actionTokens:    make(map[uuid.UUID]entities.ActionToken),
+		actionCooldowns: make(map[actionCooldownKey]time.Time),
+	}
+}
+
+// hasSessionToken reports whether a session other than except already uses
+// the token hash. The caller must hold the lock.
+func (db *Database) hasSessionToken(tokenHash string, except uuid.UUID) bool {
+	for id, session := range db.sessions {
+		if id != except && session.TokenHash() == tokenHash {
+			return true
+		}
+	}
+	return false
+}
+
+// removeSession deletes a session together with its rotated tokens. The
+// caller must hold the lock.
+func (db *Database) removeSession(id uuid.UUID) {
+	delete(db.sessions, id)
+
+	for tokenHash, session := range db.rotatedTokens {
+		if session == id {
+			delete(db.rotatedTokens, tokenHash)
+		}
+	}
+}
+
+// detach returns the session as storage would hand it back, without the
+// in-flight rotation state that only lives on the caller's copy.
+func detach(session entities.Session) entities.Session {
+	return entities.LoadSession(
+		session.Id(),
+		session.User(),
+		session.TokenHash(),
+		session.Device(),
+		session.CreatedAt(),
+		session.RefreshedAt(),
+		session.ExpiresAt(),
+		session.RevokedAt(),
+	)
+}
@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+)
+
+type ExpiredSessionRemover struct {
+	db *Database
+}
+
+var _ ports.ExpiredSessionRemover = (*ExpiredSessionRemover)(nil)
+
+func NewExpiredSessionRemover(db *Database) *ExpiredSessionRemover {
+	return &ExpiredSessionRemover{
+		db: db,
+	}
+}
+
+func (s ExpiredSessionRemover) RemoveExpiredSessions(_ context.Context, before time.Time, limit int) (int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	removed := 0
+	for id, session := range s.db.sessions {
+		if removed >= limit {
+			break
+		}
+
+		if session.ExpiresAt().Before(before) {
+			s.db.removeSession(id)
+			removed++
+		}
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type SessionAppender struct {
+	db *Database
+}
+
+var _ ports.SessionAppender = (*SessionAppender)(nil)
+
+func NewSessionAppender(db *Database) *SessionAppender {
+	return &SessionAppender{
+		db: db,
+	}
+}
+
+func (s SessionAppender) AppendSession(_ context.Context, session entities.Session) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.sessions[session.Id()]; ok {
+		return &ports.DuplicationError{
+			Source: "memory.SessionAppender",
+			Object: "session",
+			Field:  "id",
+		}
+	}
+
+	if s.db.hasSessionToken(session.TokenHash(), session.Id()) {
+		return &ports.DuplicationError{
+			Source: "memory.SessionAppender",
+			Object: "session",
+			Field:  "token",
+		}
+	}
+
+	s.db.sessions[session.Id()] = detach(session)
+	return nil
+}
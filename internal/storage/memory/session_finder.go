@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type SessionFinder struct {
+	db *Database
+}
+
+var (
+	_ ports.SessionFinder       = (*SessionFinder)(nil)
+	_ ports.RotatedTokenFinder  = (*SessionFinder)(nil)
+	_ ports.ActiveSessionFinder = (*SessionFinder)(nil)
+)
+
+func NewSessionFinder(db *Database) *SessionFinder {
+	return &SessionFinder{
+		db: db,
+	}
+}
+
+func (s SessionFinder) Find(_ context.Context, tokenHash string) (entities.Session, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	for _, session := range s.db.sessions {
+		if session.TokenHash() == tokenHash {
+			return session, nil
+		}
+	}
+
+	return entities.Session{}, &ports.NotFoundError{
+		Source: "memory.SessionFinder",
+		Object: "session",
+		Field:  "token",
+	}
+}
+
+func (s SessionFinder) FindByRotatedToken(_ context.Context, tokenHash string) (entities.Session, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	if id, ok := s.db.rotatedTokens[tokenHash]; ok {
+		if session, ok := s.db.sessions[id]; ok {
+			return session, nil
+		}
+	}
+
+	return entities.Session{}, &ports.NotFoundError{
+		Source: "memory.SessionFinder",
+		Object: "session",
+		Field:  "rotated_token",
+	}
+}
+
+func (s SessionFinder) FindActiveByUser(_ context.Context, user uuid.UUID, now time.Time) ([]entities.Session, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var sessions []entities.Session
+	for _, session := range s.db.sessions {
+		if session.User() == user && !session.IsRevoked() && !session.IsExpired(now) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	slices.SortFunc(sessions, func(a, b entities.Session) int {
+		return a.CreatedAt().Compare(b.CreatedAt())
+	})
+
+	return sessions, nil
+}
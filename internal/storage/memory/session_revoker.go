@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type SessionRevoker struct {
+	db *Database
+}
+
+var _ ports.SessionRevoker = (*SessionRevoker)(nil)
+
+func NewSessionRevoker(db *Database) *SessionRevoker {
+	return &SessionRevoker{
+		db: db,
+	}
+}
+
+func (s SessionRevoker) RevokeSession(_ context.Context, user uuid.UUID, id uuid.UUID, at time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	session, ok := s.db.sessions[id]
+	if !ok || session.User() != user || session.IsRevoked() {
+		return &ports.NotFoundError{
+			Source: "memory.SessionRevoker",
+			Object: "session",
+			Field:  "id",
+		}
+	}
+
+	s.db.sessions[id] = revoked(session, at)
+	return nil
+}
+
+func (s SessionRevoker) RevokeUserSessions(_ context.Context, user uuid.UUID, at time.Time) (int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	revokedCount := 0
+	for id, session := range s.db.sessions {
+		if session.User() == user && !session.IsRevoked() {
+			s.db.sessions[id] = revoked(session, at)
+			revokedCount++
+		}
+	}
+
+	return revokedCount, nil
+}
+
+func (s SessionRevoker) RevokeOtherSessions(_ context.Context, user uuid.UUID, keep uuid.UUID, at time.Time) (int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	revokedCount := 0
+	for id, session := range s.db.sessions {
+		if session.User() == user && id != keep && !session.IsRevoked() {
+			s.db.sessions[id] = revoked(session, at)
+			revokedCount++
+		}
+	}
+
+	return revokedCount, nil
+}
+
+func revoked(session entities.Session, at time.Time) entities.Session {
+	return entities.LoadSession(
+		session.Id(),
+		session.User(),
+		session.TokenHash(),
+		session.Device(),
+		session.CreatedAt(),
+		session.RefreshedAt(),
+		session.ExpiresAt(),
+		&at,
+	)
+}
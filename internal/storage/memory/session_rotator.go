@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type SessionRotator struct {
+	db *Database
+}
+
+var _ ports.SessionRotator = (*SessionRotator)(nil)
+
+func NewSessionRotator(db *Database) *SessionRotator {
+	return &SessionRotator{
+		db: db,
+	}
+}
+
+func (s SessionRotator) RotateSession(_ context.Context, session entities.Session) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	stored, ok := s.db.sessions[session.Id()]
+	if !ok || stored.IsRevoked() || stored.TokenHash() != session.RotatedTokenHash() {
+		return &ports.NotFoundError{
+			Source: "memory.SessionRotator",
+			Object: "session",
+			Field:  "token",
+		}
+	}
+
+	if s.db.hasSessionToken(session.TokenHash(), session.Id()) {
+		return &ports.DuplicationError{
+			Source: "memory.SessionRotator",
+			Object: "session",
+			Field:  "token",
+		}
+	}
+
+	if _, ok := s.db.rotatedTokens[stored.TokenHash()]; ok {
+		return &ports.DuplicationError{
+			Source: "memory.SessionRotator",
+			Object: "session",
+			Field:  "rotated_token",
+		}
+	}
+	s.db.rotatedTokens[stored.TokenHash()] = session.Id()
+
+	s.db.sessions[session.Id()] = entities.LoadSession(
+		stored.Id(),
+		stored.User(),
+		session.TokenHash(),
+		stored.Device(),
+		stored.CreatedAt(),
+		session.RefreshedAt(),
+		session.ExpiresAt(),
+		nil,
+	)
+	return nil
+}
@@ -0,0 +1,50 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/maxdikun/users-api/internal/storage/memory"
+	"github.com/maxdikun/users-api/internal/storage/storagetest"
+)
+
+func TestUserStorage(t *testing.T) {
+	storagetest.RunUserStorage(t, func(t *testing.T) storagetest.UserStorage {
+		db := memory.NewDatabase()
+		return storagetest.UserStorage{
+			Appender: memory.NewUserAppender(db),
+			Finder:   memory.NewUserFinder(db),
+			Updater:  memory.NewUserUpdater(db),
+		}
+	})
+}
+
+func TestSessionStorage(t *testing.T) {
+	storagetest.RunSessionStorage(t, func(t *testing.T) storagetest.SessionStorage {
+		db := memory.NewDatabase()
+		finder := memory.NewSessionFinder(db)
+		return storagetest.SessionStorage{
+			UserAppender: memory.NewUserAppender(db),
+			Appender:     memory.NewSessionAppender(db),
+			Finder:       finder,
+			Rotated:      finder,
+			Active:       finder,
+			Rotator:      memory.NewSessionRotator(db),
+			Remover:      memory.NewExpiredSessionRemover(db),
+			Revoker:      memory.NewSessionRevoker(db),
+		}
+	})
+}
+
+func TestActionTokenStorage(t *testing.T) {
+	storagetest.RunActionTokenStorage(t, func(t *testing.T) storagetest.ActionTokenStorage {
+		db := memory.NewDatabase()
+		finder := memory.NewActionTokenFinder(db)
+		return storagetest.ActionTokenStorage{
+			UserAppender: memory.NewUserAppender(db),
+			Appender:     memory.NewActionTokenAppender(db),
+			Finder:       finder,
+			Consumer:     finder,
+			Claimer:      memory.NewActionCooldownClaimer(db),
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type UserAppender struct {
+	db *Database
+}
+
+var _ ports.UserAppender = (*UserAppender)(nil)
+
+func NewUserAppender(db *Database) *UserAppender {
+	return &UserAppender{
+		db: db,
+	}
+}
+
+func (u UserAppender) AppendUser(_ context.Context, user entities.User) error {
+	u.db.mu.Lock()
+	defer u.db.mu.Unlock()
+
+	if _, ok := u.db.users[user.Id()]; ok {
+		return u.duplication("id", user.Id())
+	}
+
+	for _, existing := range u.db.users {
+		if existing.Username() == user.Username() {
+			return u.duplication("username", user.Username())
+		}
+		if existing.Email() == user.Email() {
+			return u.duplication("email", user.Email())
+		}
+	}
+
+	u.db.users[user.Id()] = user
+	return nil
+}
+
+func (u UserAppender) duplication(field string, value any) error {
+	return &ports.DuplicationError{
+		Source: "memory.UserAppender",
+		Object: "user",
+		Field:  field,
+		Value:  value,
+	}
+}
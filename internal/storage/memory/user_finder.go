@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type UserFinder struct {
+	db *Database
+}
+
+var _ ports.UserFinder = (*UserFinder)(nil)
+
+func NewUserFinder(db *Database) *UserFinder {
+	return &UserFinder{
+		db: db,
+	}
+}
+
+func (u UserFinder) FindById(_ context.Context, id uuid.UUID) (entities.User, error) {
+	u.db.mu.RLock()
+	defer u.db.mu.RUnlock()
+
+	user, ok := u.db.users[id]
+	if !ok {
+		return entities.User{}, &ports.NotFoundError{
+			Source: "memory.UserFinder",
+			Object: "user",
+			Field:  "id",
+		}
+	}
+
+	return user, nil
+}
+
+func (u UserFinder) FindByUsername(_ context.Context, username entities.Username) (entities.User, error) {
+	return u.find("username", func(user entities.User) bool {
+		return user.Username() == username
+	})
+}
+
+func (u UserFinder) FindByEmail(_ context.Context, email entities.Email) (entities.User, error) {
+	return u.find("email", func(user entities.User) bool {
+		return user.Email() == email
+	})
+}
+
+func (u UserFinder) find(field string, match func(entities.User) bool) (entities.User, error) {
+	u.db.mu.RLock()
+	defer u.db.mu.RUnlock()
+
+	for _, user := range u.db.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+
+	return entities.User{}, &ports.NotFoundError{
+		Source: "memory.UserFinder",
+		Object: "user",
+		Field:  field,
+	}
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type UserUpdater struct {
+	db *Database
+}
+
+var _ ports.UserUpdater = (*UserUpdater)(nil)
+
+func NewUserUpdater(db *Database) *UserUpdater {
+	return &UserUpdater{
+		db: db,
+	}
+}
+
+func (u UserUpdater) ConfirmEmail(_ context.Context, id uuid.UUID, at time.Time) error {
+	u.db.mu.Lock()
+	defer u.db.mu.Unlock()
+
+	user, ok := u.db.users[id]
+	if !ok {
+		return &ports.NotFoundError{
+			Source: "memory.UserUpdater",
+			Object: "user",
+			Field:  "id",
+		}
+	}
+
+	if user.EmailConfirmedAt() != nil {
+		return nil
+	}
+
+	u.db.users[id] = entities.LoadUser(
+		user.Id(),
+		user.Username(),
+		user.Email(),
+		user.Password(),
+		user.CreatedAt(),
+		&at,
+		at,
+		user.IsDeleted(),
+	)
+	return nil
+}
+
+func (u UserUpdater) UpdatePassword(_ context.Context, id uuid.UUID, oldHash, newHash entities.Password) error {
+	u.db.mu.Lock()
+	defer u.db.mu.Unlock()
+
+	user, ok := u.db.users[id]
+	if !ok || user.Password() != oldHash {
+		return &ports.NotFoundError{
+			Source: "memory.UserUpdater",
+			Object: "user",
+			Field:  "password",
+		}
+	}
+
+	u.db.users[id] = entities.LoadUser(
+		user.Id(),
+		user.Username(),
+		user.Email(),
+		newHash,
+		user.CreatedAt(),
+		user.EmailConfirmedAt(),
+		time.Now(),
+		user.IsDeleted(),
+	)
+	return nil
+}
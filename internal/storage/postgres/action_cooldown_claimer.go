@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+	"github.com/maxdikun/users-api/internal/storage/postgres/gen"
+)
+
+type ActionCooldownClaimer struct {
+	pool *pgxpool.Pool
+}
+
+var _ ports.ActionCooldownClaimer = (*ActionCooldownClaimer)(nil)
+
+func NewActionCooldownClaimer(p *pgxpool.Pool) *ActionCooldownClaimer {
+	return &ActionCooldownClaimer{
+		pool: p,
+	}
+}
+
+func (a ActionCooldownClaimer) ClaimActionCooldown(
+	ctx context.Context,
+	user uuid.UUID,
+	purpose entities.ActionPurpose,
+	at time.Time,
+	cooldown time.Duration,
+) (bool, error) {
+	queries := gen.New(a.pool)
+
+	claimed, err := queries.ClaimActionCooldown(ctx, gen.ClaimActionCooldownParams{
+		UserID:        user,
+		Purpose:       string(purpose),
+		ClaimedAt:     at,
+		ClaimedBefore: at.Add(-cooldown),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return claimed == 1, nil
+}
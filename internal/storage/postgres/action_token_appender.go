@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+	"github.com/maxdikun/users-api/internal/storage/postgres/gen"
+)
+
+type ActionTokenAppender struct {
+	pool *pgxpool.Pool
+}
+
+var _ ports.ActionTokenAppender = (*ActionTokenAppender)(nil)
+
+func NewActionTokenAppender(p *pgxpool.Pool) *ActionTokenAppender {
+	return &ActionTokenAppender{
+		pool: p,
+	}
+}
+
+func (a ActionTokenAppender) AppendActionToken(ctx context.Context, token entities.ActionToken) error {
+	queries := gen.New(a.pool)
+
+	err := queries.InsertActionToken(ctx, gen.InsertActionTokenParams{
+		ID:        token.Id(),
+		UserID:    token.User(),
+		Purpose:   string(token.Purpose()),
+		TokenHash: token.TokenHash(),
+		CreatedAt: token.CreatedAt(),
+		ExpiresAt: token.ExpiresAt(),
+		UsedAt:    token.UsedAt(),
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+			return &ports.DuplicationError{
+				Source: "postgres.ActionTokenAppender",
+				Object: "action_token",
+				Field:  uniqueViolationField(pgErr),
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+	"github.com/maxdikun/users-api/internal/storage/postgres/gen"
+)
+
+// ActionTokenFinder implements both ActionTokenFinder and
+// ActionTokenConsumer, as they read the same rows.
+type ActionTokenFinder struct {
+	pool *pgxpool.Pool
+}
+
+var (
+	_ ports.ActionTokenFinder   = (*ActionTokenFinder)(nil)
+	_ ports.ActionTokenConsumer = (*ActionTokenFinder)(nil)
+)
+
+func NewActionTokenFinder(p *pgxpool.Pool) *ActionTokenFinder {
+	return &ActionTokenFinder{
+		pool: p,
+	}
+}
+
+func (a ActionTokenFinder) FindLatestActionToken(
+	ctx context.Context,
+	user uuid.UUID,
+	purpose entities.ActionPurpose,
+) (entities.ActionToken, error) {
+	queries := gen.New(a.pool)
+
+	res, err := queries.SelectLatestActionToken(ctx, gen.SelectLatestActionTokenParams{
+		UserID:  user,
+		Purpose: string(purpose),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.ActionToken{}, &ports.NotFoundError{
+				Source: "postgres.ActionTokenFinder",
+				Object: "action_token",
+				Field:  "user_id",
+			}
+		}
+
+		return entities.ActionToken{}, err
+	}
+
+	return a.convert(res), nil
+}
+
+func (a ActionTokenFinder) FindUsableActionToken(
+	ctx context.Context,
+	purpose entities.ActionPurpose,
+	tokenHash string,
+	at time.Time,
+) (entities.ActionToken, error) {
+	queries := gen.New(a.pool)
+
+	res, err := queries.SelectUsableActionToken(ctx, gen.SelectUsableActionTokenParams{
+		TokenHash: tokenHash,
+		Purpose:   string(purpose),
+		At:        at,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.ActionToken{}, &ports.NotFoundError{
+				Source: "postgres.ActionTokenFinder",
+				Object: "action_token",
+				Field:  "token",
+			}
+		}
+
+		return entities.ActionToken{}, err
+	}
+
+	return a.convert(res), nil
+}
+
+func (a ActionTokenFinder) ConsumeActionToken(
+	ctx context.Context,
+	purpose entities.ActionPurpose,
+	tokenHash string,
+	at time.Time,
+) (entities.ActionToken, error) {
+	queries := gen.New(a.pool)
+
+	res, err := queries.ConsumeActionToken(ctx, gen.ConsumeActionTokenParams{
+		UsedAt:    &at,
+		TokenHash: tokenHash,
+		Purpose:   string(purpose),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.ActionToken{}, &ports.NotFoundError{
+				Source: "postgres.ActionTokenFinder",
+				Object: "action_token",
+				Field:  "token",
+			}
+		}
+
+		return entities.ActionToken{}, err
+	}
+
+	return a.convert(res), nil
+}
+
+func (a ActionTokenFinder) ConsumeUserActionTokens(
+	ctx context.Context,
+	user uuid.UUID,
+	purpose entities.ActionPurpose,
+	at time.Time,
+) (int, error) {
+	queries := gen.New(a.pool)
+
+	affected, err := queries.ConsumeUserActionTokens(ctx, gen.ConsumeUserActionTokensParams{
+		UsedAt:  &at,
+		UserID:  user,
+		Purpose: string(purpose),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
+
+func (a ActionTokenFinder) convert(token gen.ActionToken) entities.ActionToken {
+	return entities.LoadActionToken(
+		token.ID,
+		token.UserID,
+		entities.ActionPurpose(token.Purpose),
+		token.TokenHash,
+		token.CreatedAt,
+		token.ExpiresAt,
+		token.UsedAt,
+	)
+}
@@ -0,0 +1,29 @@
+package postgres
+
+import "github.com/jackc/pgx/v5/pgconn"
+
+const uniqueViolationCode = "23505"
+
+// uniqueConstraintFields maps unique constraints to the entity field they
+// guard. Postgres does not fill in the column name for unique violations, so
+// the constraint name is the only reliable way to tell which field clashed.
+var uniqueConstraintFields = map[string]string{
+	"users_pkey":         "id",
+	"users_username_key": "username",
+	"users_email_key":    "email",
+
+	"sessions_pkey":           "id",
+	"sessions_token_hash_key": "token",
+
+	"session_rotated_tokens_pkey": "rotated_token",
+
+	"action_tokens_pkey":           "id",
+	"action_tokens_token_hash_key": "token",
+}
+
+func uniqueViolationField(pgErr *pgconn.PgError) string {
+	if field, ok := uniqueConstraintFields[pgErr.ConstraintName]; ok {
+		return field
+	}
+	return pgErr.ConstraintName
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/storage/postgres/gen"
+)
+
+type ExpiredSessionRemover struct {
+	pool *pgxpool.Pool
+}
+
+var _ ports.ExpiredSessionRemover = (*ExpiredSessionRemover)(nil)
+
+func NewExpiredSessionRemover(p *pgxpool.Pool) *ExpiredSessionRemover {
+	return &ExpiredSessionRemover{
+		pool: p,
+	}
+}
+
+func (s ExpiredSessionRemover) RemoveExpiredSessions(ctx context.Context, before time.Time, limit int) (int, error) {
+	queries := gen.New(s.pool)
+
+	removed, err := queries.DeleteExpiredSessions(ctx, gen.DeleteExpiredSessionsParams{
+		Before:    before,
+		BatchSize: int32(limit),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(removed), nil
+}
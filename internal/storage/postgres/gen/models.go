@@ -10,6 +10,41 @@ import (
 	"github.com/google/uuid"
 )
 
+type ActionCooldown struct {
+	UserID    uuid.UUID
+	Purpose   string
+	ClaimedAt time.Time
+}
+
+type ActionToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Purpose   string
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+type Session struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	TokenHash   string
+	CreatedAt   time.Time
+	RefreshedAt time.Time
+	ExpiresAt   time.Time
+	RevokedAt   *time.Time
+	DeviceName  string
+	UserAgent   string
+	IpAddress   string
+}
+
+type SessionRotatedToken struct {
+	TokenHash string
+	SessionID uuid.UUID
+	RotatedAt time.Time
+}
+
 type User struct {
 	ID               uuid.UUID
 	Username         string
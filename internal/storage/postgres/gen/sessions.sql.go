@@ -0,0 +1,380 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: sessions.sql
+
+package gen
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions
+WHERE id IN (
+    SELECT expired.id
+    FROM sessions AS expired
+    WHERE expired.expires_at < $1
+    ORDER BY expired.expires_at
+    LIMIT $2
+)
+`
+
+type DeleteExpiredSessionsParams struct {
+	Before    time.Time
+	BatchSize int32
+}
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context, arg DeleteExpiredSessionsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredSessions, arg.Before, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const insertRotatedTokenHash = `-- name: InsertRotatedTokenHash :exec
+INSERT INTO session_rotated_tokens(
+    token_hash, session_id, rotated_at
+) VALUES(
+    $1, $2, $3
+)
+`
+
+type InsertRotatedTokenHashParams struct {
+	TokenHash string
+	SessionID uuid.UUID
+	RotatedAt time.Time
+}
+
+func (q *Queries) InsertRotatedTokenHash(ctx context.Context, arg InsertRotatedTokenHashParams) error {
+	_, err := q.db.Exec(ctx, insertRotatedTokenHash, arg.TokenHash, arg.SessionID, arg.RotatedAt)
+	return err
+}
+
+const insertSession = `-- name: InsertSession :exec
+INSERT INTO sessions(
+    id, user_id, token_hash,
+    device_name, user_agent, ip_address,
+    created_at, refreshed_at, expires_at, revoked_at
+) VALUES(
+    $1, $2, $3,
+    $4, $5, $6,
+    $7, $8, $9, $10
+)
+`
+
+type InsertSessionParams struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	TokenHash   string
+	DeviceName  string
+	UserAgent   string
+	IpAddress   string
+	CreatedAt   time.Time
+	RefreshedAt time.Time
+	ExpiresAt   time.Time
+	RevokedAt   *time.Time
+}
+
+func (q *Queries) InsertSession(ctx context.Context, arg InsertSessionParams) error {
+	_, err := q.db.Exec(ctx, insertSession,
+		arg.ID,
+		arg.UserID,
+		arg.TokenHash,
+		arg.DeviceName,
+		arg.UserAgent,
+		arg.IpAddress,
+		arg.CreatedAt,
+		arg.RefreshedAt,
+		arg.ExpiresAt,
+		arg.RevokedAt,
+	)
+	return err
+}
+
+const revokeOtherSessions = `-- name: RevokeOtherSessions :execrows
+UPDATE sessions
+SET revoked_at = $3
+WHERE user_id = $1
+  AND id <> $2
+  AND revoked_at IS NULL
+`
+
+type RevokeOtherSessionsParams struct {
+	UserID    uuid.UUID
+	ID        uuid.UUID
+	RevokedAt *time.Time
+}
+
+func (q *Queries) RevokeOtherSessions(ctx context.Context, arg RevokeOtherSessionsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeOtherSessions, arg.UserID, arg.ID, arg.RevokedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeSession = `-- name: RevokeSession :execrows
+UPDATE sessions
+SET revoked_at = $3
+WHERE id = $1
+  AND user_id = $2
+  AND revoked_at IS NULL
+`
+
+type RevokeSessionParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	RevokedAt *time.Time
+}
+
+func (q *Queries) RevokeSession(ctx context.Context, arg RevokeSessionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeSession, arg.ID, arg.UserID, arg.RevokedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeUserSessions = `-- name: RevokeUserSessions :execrows
+UPDATE sessions
+SET revoked_at = $2
+WHERE user_id = $1
+  AND revoked_at IS NULL
+`
+
+type RevokeUserSessionsParams struct {
+	UserID    uuid.UUID
+	RevokedAt *time.Time
+}
+
+func (q *Queries) RevokeUserSessions(ctx context.Context, arg RevokeUserSessionsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeUserSessions, arg.UserID, arg.RevokedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const rotateSession = `-- name: RotateSession :execrows
+UPDATE sessions
+SET token_hash = $1,
+    refreshed_at = $2,
+    expires_at = $3
+WHERE id = $4
+  AND token_hash = $5
+  AND revoked_at IS NULL
+`
+
+type RotateSessionParams struct {
+	NewTokenHash string
+	RefreshedAt  time.Time
+	ExpiresAt    time.Time
+	ID           uuid.UUID
+	OldTokenHash string
+}
+
+func (q *Queries) RotateSession(ctx context.Context, arg RotateSessionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, rotateSession,
+		arg.NewTokenHash,
+		arg.RefreshedAt,
+		arg.ExpiresAt,
+		arg.ID,
+		arg.OldTokenHash,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const selectActiveSessionsByUser = `-- name: SelectActiveSessionsByUser :many
+SELECT id, user_id, token_hash, created_at, refreshed_at, expires_at, revoked_at, device_name, user_agent, ip_address
+FROM sessions
+WHERE user_id = $1
+  AND revoked_at IS NULL
+  AND expires_at > $2
+ORDER BY created_at
+`
+
+type SelectActiveSessionsByUserParams struct {
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+}
+
+func (q *Queries) SelectActiveSessionsByUser(ctx context.Context, arg SelectActiveSessionsByUserParams) ([]Session, error) {
+	rows, err := q.db.Query(ctx, selectActiveSessionsByUser, arg.UserID, arg.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.TokenHash,
+			&i.CreatedAt,
+			&i.RefreshedAt,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.DeviceName,
+			&i.UserAgent,
+			&i.IpAddress,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectPlaintextRotatedTokens = `-- name: SelectPlaintextRotatedTokens :many
+SELECT token_hash
+FROM session_rotated_tokens
+WHERE length(token_hash) <> 64
+FOR UPDATE
+`
+
+func (q *Queries) SelectPlaintextRotatedTokens(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, selectPlaintextRotatedTokens)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var token_hash string
+		if err := rows.Scan(&token_hash); err != nil {
+			return nil, err
+		}
+		items = append(items, token_hash)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectPlaintextSessionTokens = `-- name: SelectPlaintextSessionTokens :many
+SELECT id, token_hash
+FROM sessions
+WHERE length(token_hash) <> 64
+FOR UPDATE
+`
+
+type SelectPlaintextSessionTokensRow struct {
+	ID        uuid.UUID
+	TokenHash string
+}
+
+// Tokens stored before hashing was introduced are 43 characters of base64url,
+// never the 64 hex characters of a digest.
+func (q *Queries) SelectPlaintextSessionTokens(ctx context.Context) ([]SelectPlaintextSessionTokensRow, error) {
+	rows, err := q.db.Query(ctx, selectPlaintextSessionTokens)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SelectPlaintextSessionTokensRow
+	for rows.Next() {
+		var i SelectPlaintextSessionTokensRow
+		if err := rows.Scan(&i.ID, &i.TokenHash); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectSessionByRotatedTokenHash = `-- name: SelectSessionByRotatedTokenHash :one
+SELECT sessions.id, sessions.user_id, sessions.token_hash, sessions.created_at, sessions.refreshed_at, sessions.expires_at, sessions.revoked_at, sessions.device_name, sessions.user_agent, sessions.ip_address
+FROM sessions
+JOIN session_rotated_tokens ON session_rotated_tokens.session_id = sessions.id
+WHERE session_rotated_tokens.token_hash = $1
+`
+
+func (q *Queries) SelectSessionByRotatedTokenHash(ctx context.Context, tokenHash string) (Session, error) {
+	row := q.db.QueryRow(ctx, selectSessionByRotatedTokenHash, tokenHash)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.RefreshedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.DeviceName,
+		&i.UserAgent,
+		&i.IpAddress,
+	)
+	return i, err
+}
+
+const selectSessionByTokenHash = `-- name: SelectSessionByTokenHash :one
+SELECT id, user_id, token_hash, created_at, refreshed_at, expires_at, revoked_at, device_name, user_agent, ip_address
+FROM sessions
+WHERE token_hash = $1
+`
+
+func (q *Queries) SelectSessionByTokenHash(ctx context.Context, tokenHash string) (Session, error) {
+	row := q.db.QueryRow(ctx, selectSessionByTokenHash, tokenHash)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.RefreshedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.DeviceName,
+		&i.UserAgent,
+		&i.IpAddress,
+	)
+	return i, err
+}
+
+const updateRotatedTokenHash = `-- name: UpdateRotatedTokenHash :exec
+UPDATE session_rotated_tokens
+SET token_hash = $1
+WHERE token_hash = $2
+`
+
+type UpdateRotatedTokenHashParams struct {
+	NewTokenHash string
+	OldTokenHash string
+}
+
+func (q *Queries) UpdateRotatedTokenHash(ctx context.Context, arg UpdateRotatedTokenHashParams) error {
+	_, err := q.db.Exec(ctx, updateRotatedTokenHash, arg.NewTokenHash, arg.OldTokenHash)
+	return err
+}
+
+const updateSessionTokenHash = `-- name: UpdateSessionTokenHash :exec
+UPDATE sessions
+SET token_hash = $1
+WHERE id = $2
+`
+
+type UpdateSessionTokenHashParams struct {
+	TokenHash string
+	ID        uuid.UUID
+}
+
+func (q *Queries) UpdateSessionTokenHash(ctx context.Context, arg UpdateSessionTokenHashParams) error {
+	_, err := q.db.Exec(ctx, updateSessionTokenHash, arg.TokenHash, arg.ID)
+	return err
+}
@@ -12,6 +12,29 @@ import (
 	"github.com/google/uuid"
 )
 
+const confirmEmail = `-- name: ConfirmEmail :execrows
+UPDATE users
+SET email_confirmed_at = COALESCE(email_confirmed_at, $1::timestamptz),
+    updated_at = CASE
+        WHEN email_confirmed_at IS NULL THEN $1::timestamptz
+        ELSE updated_at
+    END
+WHERE id = $2
+`
+
+type ConfirmEmailParams struct {
+	ConfirmedAt time.Time
+	ID          uuid.UUID
+}
+
+func (q *Queries) ConfirmEmail(ctx context.Context, arg ConfirmEmailParams) (int64, error) {
+	result, err := q.db.Exec(ctx, confirmEmail, arg.ConfirmedAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const insertUser = `-- name: InsertUser :exec
 INSERT INTO users(
     id, username, email, password,
@@ -112,3 +135,31 @@ func (q *Queries) SelectUserByUsername(ctx context.Context, username string) (Us
 	)
 	return i, err
 }
+
+const updatePassword = `-- name: UpdatePassword :execrows
+UPDATE users
+SET password = $1,
+    updated_at = $2
+WHERE id = $3
+  AND password = $4
+`
+
+type UpdatePasswordParams struct {
+	NewPassword string
+	UpdatedAt   time.Time
+	ID          uuid.UUID
+	OldPassword string
+}
+
+func (q *Queries) UpdatePassword(ctx context.Context, arg UpdatePasswordParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updatePassword,
+		arg.NewPassword,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.OldPassword,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
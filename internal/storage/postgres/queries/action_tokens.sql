@@ -0,0 +1,48 @@
+-- name: InsertActionToken :exec
+INSERT INTO action_tokens(
+    id, user_id, purpose, token_hash,
+    created_at, expires_at, used_at
+) VALUES(
+    $1, $2, $3, $4,
+    $5, $6, $7
+);
+
+-- name: SelectLatestActionToken :one
+SELECT *
+FROM action_tokens
+WHERE user_id = $1 AND purpose = $2
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: ConsumeActionToken :one
+UPDATE action_tokens
+SET used_at = sqlc.arg(used_at)
+WHERE token_hash = sqlc.arg(token_hash)
+  AND purpose = sqlc.arg(purpose)
+  AND used_at IS NULL
+  AND expires_at > sqlc.arg(used_at)
+RETURNING *;
+
+-- name: ConsumeUserActionTokens :execrows
+UPDATE action_tokens
+SET used_at = sqlc.arg(used_at)
+WHERE user_id = sqlc.arg(user_id)
+  AND purpose = sqlc.arg(purpose)
+  AND used_at IS NULL;
+
+-- name: SelectUsableActionToken :one
+SELECT *
+FROM action_tokens
+WHERE token_hash = sqlc.arg(token_hash)
+  AND purpose = sqlc.arg(purpose)
+  AND used_at IS NULL
+  AND expires_at > sqlc.arg(at);
+
+-- name: ClaimActionCooldown :execrows
+-- The conflicting row is locked and the WHERE clause re-checked against its
+-- latest version, so of concurrent claims at most one updates it.
+INSERT INTO action_cooldowns(user_id, purpose, claimed_at)
+VALUES(sqlc.arg(user_id), sqlc.arg(purpose), sqlc.arg(claimed_at))
+ON CONFLICT (user_id, purpose) DO UPDATE
+SET claimed_at = excluded.claimed_at
+WHERE action_cooldowns.claimed_at <= sqlc.arg(claimed_before);
@@ -0,0 +1,99 @@
+-- name: InsertSession :exec
+INSERT INTO sessions(
+    id, user_id, token_hash,
+    device_name, user_agent, ip_address,
+    created_at, refreshed_at, expires_at, revoked_at
+) VALUES(
+    $1, $2, $3,
+    $4, $5, $6,
+    $7, $8, $9, $10
+);
+
+-- name: SelectSessionByTokenHash :one
+SELECT *
+FROM sessions
+WHERE token_hash = $1;
+
+-- name: SelectActiveSessionsByUser :many
+SELECT *
+FROM sessions
+WHERE user_id = $1
+  AND revoked_at IS NULL
+  AND expires_at > $2
+ORDER BY created_at;
+
+-- name: RotateSession :execrows
+UPDATE sessions
+SET token_hash = @new_token_hash,
+    refreshed_at = @refreshed_at,
+    expires_at = @expires_at
+WHERE id = @id
+  AND token_hash = @old_token_hash
+  AND revoked_at IS NULL;
+
+-- name: InsertRotatedTokenHash :exec
+INSERT INTO session_rotated_tokens(
+    token_hash, session_id, rotated_at
+) VALUES(
+    $1, $2, $3
+);
+
+-- name: SelectSessionByRotatedTokenHash :one
+SELECT sessions.*
+FROM sessions
+JOIN session_rotated_tokens ON session_rotated_tokens.session_id = sessions.id
+WHERE session_rotated_tokens.token_hash = $1;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions
+WHERE id IN (
+    SELECT expired.id
+    FROM sessions AS expired
+    WHERE expired.expires_at < sqlc.arg(before)
+    ORDER BY expired.expires_at
+    LIMIT sqlc.arg(batch_size)
+);
+
+-- name: RevokeSession :execrows
+UPDATE sessions
+SET revoked_at = $3
+WHERE id = $1
+  AND user_id = $2
+  AND revoked_at IS NULL;
+
+-- name: RevokeUserSessions :execrows
+UPDATE sessions
+SET revoked_at = $2
+WHERE user_id = $1
+  AND revoked_at IS NULL;
+
+-- name: RevokeOtherSessions :execrows
+UPDATE sessions
+SET revoked_at = $3
+WHERE user_id = $1
+  AND id <> $2
+  AND revoked_at IS NULL;
+
+-- name: SelectPlaintextSessionTokens :many
+-- Tokens stored before hashing was introduced are 43 characters of base64url,
+-- never the 64 hex characters of a digest.
+SELECT id, token_hash
+FROM sessions
+WHERE length(token_hash) <> 64
+FOR UPDATE;
+
+-- name: UpdateSessionTokenHash :exec
+UPDATE sessions
+SET token_hash = @token_hash
+WHERE id = @id;
+
+-- name: SelectPlaintextRotatedTokens :many
+SELECT token_hash
+FROM session_rotated_tokens
+WHERE length(token_hash) <> 64
+FOR UPDATE;
+
+-- name: UpdateRotatedTokenHash :exec
+UPDATE session_rotated_tokens
+SET token_hash = @new_token_hash
+WHERE token_hash = @old_token_hash;
@@ -22,3 +22,19 @@ SELECT *
 FROM users
 WHERE email = $1;
 
+
+-- name: ConfirmEmail :execrows
+UPDATE users
+SET email_confirmed_at = COALESCE(email_confirmed_at, @confirmed_at::timestamptz),
+    updated_at = CASE
+        WHEN email_confirmed_at IS NULL THEN @confirmed_at::timestamptz
+        ELSE updated_at
+    END
+WHERE id = @id;
+
+-- name: UpdatePassword :execrows
+UPDATE users
+SET password = @new_password,
+    updated_at = @updated_at
+WHERE id = @id
+  AND password = @old_password;
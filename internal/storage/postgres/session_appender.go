@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+	"github.com/maxdikun/users-api/internal/storage/postgres/gen"
+)
+
+type SessionAppender struct {
+	pool *pgxpool.Pool
+}
+
+var _ ports.SessionAppender = (*SessionAppender)(nil)
+
+func (s SessionAppender) AppendSession(ctx context.Context, session entities.Session) error {
+	queries := gen.New(s.pool)
+
+	err := queries.InsertSession(ctx, gen.InsertSessionParams{
+		ID:          session.Id(),
+		UserID:      session.User(),
+		TokenHash:   session.TokenHash(),
+		DeviceName:  session.Device().Name,
+		UserAgent:   session.Device().UserAgent,
+		IpAddress:   session.Device().IP,
+		CreatedAt:   session.CreatedAt(),
+		RefreshedAt: session.RefreshedAt(),
+		ExpiresAt:   session.ExpiresAt(),
+		RevokedAt:   session.RevokedAt(),
+	})
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case uniqueViolationCode:
+				return &ports.DuplicationError{
+					Source: "postgres.SessionAppender",
+					Object: "session",
+					Field:  uniqueViolationField(pgErr),
+				}
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func NewSessionAppender(p *pgxpool.Pool) *SessionAppender {
+	return &SessionAppender{
+		pool: p,
+	}
+}
@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+	"github.com/maxdikun/users-api/internal/storage/postgres/gen"
+)
+
+type SessionFinder struct {
+	pool *pgxpool.Pool
+}
+
+var (
+	_ ports.SessionFinder       = (*SessionFinder)(nil)
+	_ ports.RotatedTokenFinder  = (*SessionFinder)(nil)
+	_ ports.ActiveSessionFinder = (*SessionFinder)(nil)
+)
+
+func NewSessionFinder(p *pgxpool.Pool) *SessionFinder {
+	return &SessionFinder{
+		pool: p,
+	}
+}
+
+func (s SessionFinder) Find(ctx context.Context, tokenHash string) (entities.Session, error) {
+	queries := gen.New(s.pool)
+
+	res, err := queries.SelectSessionByTokenHash(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.Session{}, &ports.NotFoundError{
+				Source: "postgres.SessionFinder",
+				Object: "session",
+				Field:  "token",
+			}
+		}
+
+		return entities.Session{}, err
+	}
+
+	return s.convert(res), nil
+}
+
+func (s SessionFinder) FindByRotatedToken(ctx context.Context, tokenHash string) (entities.Session, error) {
+	queries := gen.New(s.pool)
+
+	res, err := queries.SelectSessionByRotatedTokenHash(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.Session{}, &ports.NotFoundError{
+				Source: "postgres.SessionFinder",
+				Object: "session",
+				Field:  "rotated_token",
+			}
+		}
+
+		return entities.Session{}, err
+	}
+
+	return s.convert(res), nil
+}
+
+func (s SessionFinder) FindActiveByUser(ctx context.Context, user uuid.UUID, now time.Time) ([]entities.Session, error) {
+	queries := gen.New(s.pool)
+
+	rows, err := queries.SelectActiveSessionsByUser(ctx, gen.SelectActiveSessionsByUserParams{
+		UserID:    user,
+		ExpiresAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]entities.Session, 0, len(rows))
+	for _, row := range rows {
+		sessions = append(sessions, s.convert(row))
+	}
+
+	return sessions, nil
+}
+
+func (s SessionFinder) convert(session gen.Session) entities.Session {
+	return entities.LoadSession(
+		session.ID,
+		session.UserID,
+		session.TokenHash,
+		entities.Device{
+			Name:      session.DeviceName,
+			UserAgent: session.UserAgent,
+			IP:        session.IpAddress,
+		},
+		session.CreatedAt,
+		session.RefreshedAt,
+		session.ExpiresAt,
+		session.RevokedAt,
+	)
+}
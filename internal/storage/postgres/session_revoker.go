@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/storage/postgres/gen"
+)
+
+type SessionRevoker struct {
+	pool *pgxpool.Pool
+}
+
+var _ ports.SessionRevoker = (*SessionRevoker)(nil)
+
+func NewSessionRevoker(p *pgxpool.Pool) *SessionRevoker {
+	return &SessionRevoker{
+		pool: p,
+	}
+}
+
+func (s SessionRevoker) RevokeSession(ctx context.Context, user uuid.UUID, id uuid.UUID, at time.Time) error {
+	queries := gen.New(s.pool)
+
+	affected, err := queries.RevokeSession(ctx, gen.RevokeSessionParams{
+		ID:        id,
+		UserID:    user,
+		RevokedAt: &at,
+	})
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return &ports.NotFoundError{
+			Source: "postgres.SessionRevoker",
+			Object: "session",
+			Field:  "id",
+		}
+	}
+
+	return nil
+}
+
+func (s SessionRevoker) RevokeUserSessions(ctx context.Context, user uuid.UUID, at time.Time) (int, error) {
+	queries := gen.New(s.pool)
+
+	affected, err := queries.RevokeUserSessions(ctx, gen.RevokeUserSessionsParams{
+		UserID:    user,
+		RevokedAt: &at,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
+
+func (s SessionRevoker) RevokeOtherSessions(ctx context.Context, user uuid.UUID, keep uuid.UUID, at time.Time) (int, error) {
+	queries := gen.New(s.pool)
+
+	affected, err := queries.RevokeOtherSessions(ctx, gen.RevokeOtherSessionsParams{
+		UserID:    user,
+		ID:        keep,
+		RevokedAt: &at,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+	"github.com/maxdikun/users-api/internal/storage/postgres/gen"
+)
+
+type SessionRotator struct {
+	pool *pgxpool.Pool
+}
+
+var _ ports.SessionRotator = (*SessionRotator)(nil)
+
+func NewSessionRotator(p *pgxpool.Pool) *SessionRotator {
+	return &SessionRotator{
+		pool: p,
+	}
+}
+
+// RotateSession swaps the session's token only if the row still holds the
+// replaced one and is not revoked, and records the hash of the replaced token
+// so that later reuse of it can be detected. Of two concurrent rotations of
+// the same token the second waits for the first and then matches no row.
+func (s SessionRotator) RotateSession(ctx context.Context, session entities.Session) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	queries := gen.New(tx)
+
+	affected, err := queries.RotateSession(ctx, gen.RotateSessionParams{
+		ID:           session.Id(),
+		OldTokenHash: session.RotatedTokenHash(),
+		NewTokenHash: session.TokenHash(),
+		RefreshedAt:  session.RefreshedAt(),
+		ExpiresAt:    session.ExpiresAt(),
+	})
+	if err != nil {
+		return s.convertError(err)
+	}
+
+	if affected == 0 {
+		return &ports.NotFoundError{
+			Source: "postgres.SessionRotator",
+			Object: "session",
+			Field:  "token",
+		}
+	}
+
+	err = queries.InsertRotatedTokenHash(ctx, gen.InsertRotatedTokenHashParams{
+		TokenHash: session.RotatedTokenHash(),
+		SessionID: session.Id(),
+		RotatedAt: time.Now(),
+	})
+	if err != nil {
+		return s.convertError(err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s SessionRotator) convertError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case uniqueViolationCode:
+			return &ports.DuplicationError{
+				Source: "postgres.SessionRotator",
+				Object: "session",
+				Field:  uniqueViolationField(pgErr),
+			}
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,169 @@
+package postgres_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maxdikun/users-api/internal/application"
+	"github.com/maxdikun/users-api/internal/storage/postgres"
+	"github.com/maxdikun/users-api/internal/storage/storagetest"
+)
+
+// newPool connects to POSTGRES_TEST_URL, a migrated database the tests may
+// wipe, and skips the test when it is not set.
+func newPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv("POSTGRES_TEST_URL")
+	if url == "" {
+		t.Skip("POSTGRES_TEST_URL is not set")
+	}
+
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatalf("failed to create postgres pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	if err := pool.Ping(context.Background()); err != nil {
+		t.Fatalf("failed to connect to postgres: %v", err)
+	}
+	return pool
+}
+
+// truncate empties every table, as the suite expects fresh storage per
+// subtest.
+func truncate(t *testing.T, pool *pgxpool.Pool) {
+	t.Helper()
+
+	_, err := pool.Exec(
+		context.Background(),
+		"TRUNCATE users, sessions, session_rotated_tokens, action_tokens, action_cooldowns CASCADE",
+	)
+	if err != nil {
+		t.Fatalf("failed to truncate tables: %v", err)
+	}
+}
+
+func TestUserStorage(t *testing.T) {
+	pool := newPool(t)
+
+	storagetest.RunUserStorage(t, func(t *testing.T) storagetest.UserStorage {
+		truncate(t, pool)
+		return storagetest.UserStorage{
+			Appender: postgres.NewUserAppender(pool),
+			Finder:   postgres.NewUserFinder(pool),
+			Updater:  postgres.NewUserUpdater(pool),
+		}
+	})
+}
+
+func TestSessionStorage(t *testing.T) {
+	pool := newPool(t)
+
+	storagetest.RunSessionStorage(t, func(t *testing.T) storagetest.SessionStorage {
+		truncate(t, pool)
+		finder := postgres.NewSessionFinder(pool)
+		return storagetest.SessionStorage{
+			UserAppender: postgres.NewUserAppender(pool),
+			Appender:     postgres.NewSessionAppender(pool),
+			Finder:       finder,
+			Rotated:      finder,
+			Active:       finder,
+			Rotator:      postgres.NewSessionRotator(pool),
+			Remover:      postgres.NewExpiredSessionRemover(pool),
+			Revoker:      postgres.NewSessionRevoker(pool),
+		}
+	})
+}
+
+func TestActionTokenStorage(t *testing.T) {
+	pool := newPool(t)
+
+	storagetest.RunActionTokenStorage(t, func(t *testing.T) storagetest.ActionTokenStorage {
+		truncate(t, pool)
+		finder := postgres.NewActionTokenFinder(pool)
+		return storagetest.ActionTokenStorage{
+			UserAppender: postgres.NewUserAppender(pool),
+			Appender:     postgres.NewActionTokenAppender(pool),
+			Finder:       finder,
+			Consumer:     finder,
+			Claimer:      postgres.NewActionCooldownClaimer(pool),
+		}
+	})
+}
+
+func TestTokenMigrator(t *testing.T) {
+	pool := newPool(t)
+	truncate(t, pool)
+	ctx := context.Background()
+
+	// Rows as they were stored before hashing: 43 characters of base64url.
+	const plain, rotated = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA", "BBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB"
+	hashed := strings.Repeat("c", 64)
+	user := uuid.New()
+	_, err := pool.Exec(
+		ctx,
+		`INSERT INTO users(id, username, email, password, created_at, updated_at)
+		 VALUES($1, 'alice', 'alice@example.com', 'hash', now(), now())`,
+		user,
+	)
+	if err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+
+	sessions := []struct {
+		id    uuid.UUID
+		token string
+	}{{uuid.New(), plain}, {uuid.New(), hashed}}
+	for _, session := range sessions {
+		_, err := pool.Exec(
+			ctx,
+			`INSERT INTO sessions(id, user_id, token_hash, created_at, refreshed_at, expires_at)
+			 VALUES($1, $2, $3, now(), now(), now() + interval '1 hour')`,
+			session.id, user, session.token,
+		)
+		if err != nil {
+			t.Fatalf("failed to insert session: %v", err)
+		}
+	}
+	_, err = pool.Exec(
+		ctx, "INSERT INTO session_rotated_tokens(token_hash, session_id, rotated_at) VALUES($1, $2, now())",
+		rotated, sessions[0].id,
+	)
+	if err != nil {
+		t.Fatalf("failed to insert rotated token: %v", err)
+	}
+
+	hash := func(token string) string { return application.HashRefreshToken([]byte("pepper"), token) }
+	migrator := postgres.NewTokenMigrator(pool)
+
+	count, err := migrator.HashPlaintextTokens(ctx, hash)
+	if err != nil {
+		t.Fatalf("HashPlaintextTokens() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("HashPlaintextTokens() = %d, want 2", count)
+	}
+
+	finder := postgres.NewSessionFinder(pool)
+	if _, err := finder.Find(ctx, hash(plain)); err != nil {
+		t.Errorf("session with hashed plaintext token not found: %v", err)
+	}
+	if _, err := finder.Find(ctx, hashed); err != nil {
+		t.Errorf("session with hashed token not found: %v", err)
+	}
+	if _, err := finder.FindByRotatedToken(ctx, hash(rotated)); err != nil {
+		t.Errorf("session of hashed rotated token not found: %v", err)
+	}
+
+	count, err = migrator.HashPlaintextTokens(ctx, hash)
+	if err != nil || count != 0 {
+		t.Errorf("second HashPlaintextTokens() = %d, %v, want 0, nil", count, err)
+	}
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maxdikun/users-api/internal/storage/postgres/gen"
+)
+
+// TokenMigrator finishes the migration to hashed refresh tokens, which cannot
+// hash the tokens of existing sessions itself as only the API knows the
+// pepper.
+type TokenMigrator struct {
+	pool *pgxpool.Pool
+}
+
+func NewTokenMigrator(p *pgxpool.Pool) *TokenMigrator {
+	return &TokenMigrator{
+		pool: p,
+	}
+}
+
+// HashPlaintextTokens replaces the refresh tokens still stored in plaintext,
+// current and rotated ones, by their hash and returns how many it replaced.
+// The rows are locked, so replicas starting together do not hash a token
+// twice.
+func (m TokenMigrator) HashPlaintextTokens(ctx context.Context, hash func(token string) string) (int, error) {
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	queries := gen.New(tx)
+
+	sessions, err := queries.SelectPlaintextSessionTokens(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, session := range sessions {
+		err := queries.UpdateSessionTokenHash(ctx, gen.UpdateSessionTokenHashParams{
+			ID:        session.ID,
+			TokenHash: hash(session.TokenHash),
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	rotated, err := queries.SelectPlaintextRotatedTokens(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, token := range rotated {
+		err := queries.UpdateRotatedTokenHash(ctx, gen.UpdateRotatedTokenHashParams{
+			OldTokenHash: token,
+			NewTokenHash: hash(token),
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(sessions) + len(rotated), nil
+}
@@ -36,11 +36,11 @@ func (u UserAppender) AppendUser(ctx context.Context, user entities.User) error
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
 			switch pgErr.Code {
-			case "23505":
+			case uniqueViolationCode:
 				return &ports.DuplicationError{
 					Source: "postgres.UserAppender",
 					Object: "user",
-					Field:  pgErr.ColumnName,
+					Field:  uniqueViolationField(pgErr),
 				}
 			}
 		}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"errors"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/maxdikun/users-api/internal/application/ports"
@@ -22,6 +23,25 @@ func NewUserFinder(p *pgxpool.Pool) *UserFinder {
 	}
 }
 
+func (u UserFinder) FindById(ctx context.Context, id uuid.UUID) (entities.User, error) {
+	queries := gen.New(u.pool)
+
+	res, err := queries.SelectUserById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.User{}, &ports.NotFoundError{
+				Source: "postgres.UserFinder",
+				Object: "user",
+				Field:  "id",
+			}
+		}
+
+		return entities.User{}, err
+	}
+
+	return u.convert(res), nil
+}
+
 func (u UserFinder) FindByUsername(ctx context.Context, username entities.Username) (entities.User, error) {
 	queries := gen.New(u.pool)
 
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+	"github.com/maxdikun/users-api/internal/storage/postgres/gen"
+)
+
+type UserUpdater struct {
+	pool *pgxpool.Pool
+}
+
+var _ ports.UserUpdater = (*UserUpdater)(nil)
+
+func NewUserUpdater(p *pgxpool.Pool) *UserUpdater {
+	return &UserUpdater{
+		pool: p,
+	}
+}
+
+func (u UserUpdater) ConfirmEmail(ctx context.Context, id uuid.UUID, at time.Time) error {
+	queries := gen.New(u.pool)
+
+	affected, err := queries.ConfirmEmail(ctx, gen.ConfirmEmailParams{
+		ID:          id,
+		ConfirmedAt: at,
+	})
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return &ports.NotFoundError{
+			Source: "postgres.UserUpdater",
+			Object: "user",
+			Field:  "id",
+		}
+	}
+
+	return nil
+}
+
+func (u UserUpdater) UpdatePassword(ctx context.Context, id uuid.UUID, oldHash, newHash entities.Password) error {
+	queries := gen.New(u.pool)
+
+	affected, err := queries.UpdatePassword(ctx, gen.UpdatePasswordParams{
+		ID:          id,
+		OldPassword: string(oldHash),
+		NewPassword: string(newHash),
+		UpdatedAt:   time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return &ports.NotFoundError{
+			Source: "postgres.UserUpdater",
+			Object: "user",
+			Field:  "password",
+		}
+	}
+
+	return nil
+}
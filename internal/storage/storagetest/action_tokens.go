@@ -0,0 +1,266 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// ActionTokenStorage bundles the action token ports of a single storage
+// backend. UserAppender is used to create the users tokens belong to.
+type ActionTokenStorage struct {
+	UserAppender ports.UserAppender
+	Appender     ports.ActionTokenAppender
+	Finder       ports.ActionTokenFinder
+	Consumer     ports.ActionTokenConsumer
+	Claimer      ports.ActionCooldownClaimer
+}
+
+// otherPurpose is a purpose no token in the suite is issued for.
+const otherPurpose entities.ActionPurpose = "storagetest_other"
+
+// RunActionTokenStorage runs the conformance suite for ActionTokenAppender,
+// ActionTokenFinder, ActionTokenConsumer and ActionCooldownClaimer.
+// newStorage is called once per subtest and must return empty storage.
+func RunActionTokenStorage(t *testing.T, newStorage func(t *testing.T) ActionTokenStorage) {
+	setup := func(t *testing.T) (context.Context, ActionTokenStorage, entities.User) {
+		ctx, s := context.Background(), newStorage(t)
+		user := newUser()
+		requireNoError(t, s.UserAppender.AppendUser(ctx, user), "append user")
+		return ctx, s, user
+	}
+
+	t.Run("FindLatestActionToken round-trips every field", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		token := newActionToken(user.Id(), entities.ActionEmailConfirmation)
+		requireNoError(t, s.Appender.AppendActionToken(ctx, token), "append action token")
+
+		got, err := s.Finder.FindLatestActionToken(ctx, user.Id(), token.Purpose())
+		requireNoError(t, err, "find latest action token")
+		assertActionTokenEqual(t, token, got)
+	})
+
+	t.Run("FindLatestActionToken returns the newest token of the purpose", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		older := newActionToken(user.Id(), entities.ActionEmailConfirmation)
+		older = withActionTokenTimes(older, older.CreatedAt().Add(-time.Hour), older.ExpiresAt())
+		newer := newActionToken(user.Id(), entities.ActionEmailConfirmation)
+		other := newActionToken(user.Id(), otherPurpose)
+		other = withActionTokenTimes(other, newer.CreatedAt().Add(time.Second), other.ExpiresAt())
+		for _, token := range []entities.ActionToken{newer, older, other} {
+			requireNoError(t, s.Appender.AppendActionToken(ctx, token), "append action token")
+		}
+
+		got, err := s.Finder.FindLatestActionToken(ctx, user.Id(), entities.ActionEmailConfirmation)
+		requireNoError(t, err, "find latest action token")
+		assertActionTokenEqual(t, newer, got)
+	})
+
+	t.Run("FindLatestActionToken without tokens", func(t *testing.T) {
+		ctx, s, user := setup(t)
+
+		_, err := s.Finder.FindLatestActionToken(ctx, user.Id(), entities.ActionEmailConfirmation)
+		requireNotFound(t, err, "user_id")
+	})
+
+	t.Run("FindUsableActionToken leaves the token unused", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		token := newActionToken(user.Id(), entities.ActionPasswordReset)
+		requireNoError(t, s.Appender.AppendActionToken(ctx, token), "append action token")
+
+		got, err := s.Finder.FindUsableActionToken(ctx, token.Purpose(), token.TokenHash(), now())
+		requireNoError(t, err, "find usable action token")
+		assertActionTokenEqual(t, token, got)
+
+		_, err = s.Consumer.ConsumeActionToken(ctx, token.Purpose(), token.TokenHash(), now())
+		requireNoError(t, err, "consume after find")
+	})
+
+	t.Run("FindUsableActionToken skips used, expired and other tokens", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		token := newActionToken(user.Id(), entities.ActionPasswordReset)
+		requireNoError(t, s.Appender.AppendActionToken(ctx, token), "append action token")
+
+		_, err := s.Finder.FindUsableActionToken(ctx, token.Purpose(), token.TokenHash(), token.ExpiresAt())
+		requireNotFound(t, err, "token")
+		_, err = s.Finder.FindUsableActionToken(ctx, otherPurpose, token.TokenHash(), now())
+		requireNotFound(t, err, "token")
+
+		_, err = s.Consumer.ConsumeActionToken(ctx, token.Purpose(), token.TokenHash(), now())
+		requireNoError(t, err, "consume action token")
+		_, err = s.Finder.FindUsableActionToken(ctx, token.Purpose(), token.TokenHash(), now())
+		requireNotFound(t, err, "token")
+	})
+
+	t.Run("duplicate token", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		token := newActionToken(user.Id(), entities.ActionEmailConfirmation)
+		requireNoError(t, s.Appender.AppendActionToken(ctx, token), "append action token")
+
+		clash := withActionTokenHash(newActionToken(user.Id(), entities.ActionEmailConfirmation), token.TokenHash())
+		requireDuplication(t, s.Appender.AppendActionToken(ctx, clash), "token")
+	})
+
+	t.Run("duplicate id", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		token := newActionToken(user.Id(), entities.ActionEmailConfirmation)
+		requireNoError(t, s.Appender.AppendActionToken(ctx, token), "append action token")
+
+		clash := withActionTokenId(newActionToken(user.Id(), entities.ActionEmailConfirmation), token.Id())
+		requireDuplication(t, s.Appender.AppendActionToken(ctx, clash), "id")
+	})
+
+	t.Run("ConsumeActionToken marks the token used", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		token := newActionToken(user.Id(), entities.ActionEmailConfirmation)
+		requireNoError(t, s.Appender.AppendActionToken(ctx, token), "append action token")
+
+		at := now()
+		got, err := s.Consumer.ConsumeActionToken(ctx, token.Purpose(), token.TokenHash(), at)
+		requireNoError(t, err, "consume action token")
+
+		want := entities.LoadActionToken(
+			token.Id(), token.User(), token.Purpose(), token.TokenHash(),
+			token.CreatedAt(), token.ExpiresAt(), &at,
+		)
+		assertActionTokenEqual(t, want, got)
+
+		latest, err := s.Finder.FindLatestActionToken(ctx, user.Id(), token.Purpose())
+		requireNoError(t, err, "find latest action token")
+		assertActionTokenEqual(t, want, latest)
+	})
+
+	t.Run("ConsumeActionToken twice", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		token := newActionToken(user.Id(), entities.ActionEmailConfirmation)
+		requireNoError(t, s.Appender.AppendActionToken(ctx, token), "append action token")
+
+		_, err := s.Consumer.ConsumeActionToken(ctx, token.Purpose(), token.TokenHash(), now())
+		requireNoError(t, err, "consume action token")
+
+		_, err = s.Consumer.ConsumeActionToken(ctx, token.Purpose(), token.TokenHash(), now())
+		requireNotFound(t, err, "token")
+	})
+
+	t.Run("ConsumeActionToken after expiry", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		token := newActionToken(user.Id(), entities.ActionEmailConfirmation)
+		requireNoError(t, s.Appender.AppendActionToken(ctx, token), "append action token")
+
+		_, err := s.Consumer.ConsumeActionToken(ctx, token.Purpose(), token.TokenHash(), token.ExpiresAt())
+		requireNotFound(t, err, "token")
+	})
+
+	t.Run("ConsumeActionToken for another purpose", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		token := newActionToken(user.Id(), entities.ActionEmailConfirmation)
+		requireNoError(t, s.Appender.AppendActionToken(ctx, token), "append action token")
+
+		_, err := s.Consumer.ConsumeActionToken(ctx, otherPurpose, token.TokenHash(), now())
+		requireNotFound(t, err, "token")
+	})
+
+	t.Run("ConsumeActionToken of unknown token", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+
+		_, err := s.Consumer.ConsumeActionToken(ctx, entities.ActionEmailConfirmation, "missing-"+uuid.NewString(), now())
+		requireNotFound(t, err, "token")
+	})
+	t.Run("ConsumeUserActionTokens uses up the user's tokens of the purpose", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		other := newUser()
+		requireNoError(t, s.UserAppender.AppendUser(ctx, other), "append other user")
+
+		first := newActionToken(user.Id(), entities.ActionPasswordReset)
+		second := newActionToken(user.Id(), entities.ActionPasswordReset)
+		used := newActionToken(user.Id(), entities.ActionPasswordReset)
+		confirmation := newActionToken(user.Id(), entities.ActionEmailConfirmation)
+		foreign := newActionToken(other.Id(), entities.ActionPasswordReset)
+		for _, token := range []entities.ActionToken{first, second, used, confirmation, foreign} {
+			requireNoError(t, s.Appender.AppendActionToken(ctx, token), "append action token")
+		}
+		_, err := s.Consumer.ConsumeActionToken(ctx, used.Purpose(), used.TokenHash(), now())
+		requireNoError(t, err, "consume action token")
+
+		consumed, err := s.Consumer.ConsumeUserActionTokens(ctx, user.Id(), entities.ActionPasswordReset, now())
+		requireNoError(t, err, "consume user action tokens")
+		if consumed != 2 {
+			t.Fatalf("expected 2 consumed tokens, got %d", consumed)
+		}
+
+		for _, token := range []entities.ActionToken{first, second} {
+			_, err = s.Finder.FindUsableActionToken(ctx, token.Purpose(), token.TokenHash(), now())
+			requireNotFound(t, err, "token")
+		}
+		for _, token := range []entities.ActionToken{confirmation, foreign} {
+			_, err = s.Finder.FindUsableActionToken(ctx, token.Purpose(), token.TokenHash(), now())
+			requireNoError(t, err, "find untouched action token")
+		}
+	})
+	t.Run("ClaimActionCooldown once per cooldown", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		at := now()
+
+		claims := []struct {
+			purpose entities.ActionPurpose
+			at      time.Time
+			want    bool
+		}{
+			{entities.ActionPasswordReset, at, true},
+			{entities.ActionPasswordReset, at.Add(time.Minute - time.Second), false},
+			{entities.ActionEmailConfirmation, at, true},
+			{entities.ActionPasswordReset, at.Add(time.Minute), true},
+			{entities.ActionPasswordReset, at.Add(time.Minute + time.Second), false},
+		}
+		for _, claim := range claims {
+			got, err := s.Claimer.ClaimActionCooldown(ctx, user.Id(), claim.purpose, claim.at, time.Minute)
+			requireNoError(t, err, "claim action cooldown")
+			if got != claim.want {
+				t.Fatalf("ClaimActionCooldown(%s, %v) = %v, want %v", claim.purpose, claim.at, got, claim.want)
+			}
+		}
+
+		other := newUser()
+		requireNoError(t, s.UserAppender.AppendUser(ctx, other), "append other user")
+		got, err := s.Claimer.ClaimActionCooldown(ctx, other.Id(), entities.ActionPasswordReset, at, time.Minute)
+		requireNoError(t, err, "claim action cooldown of other user")
+		if !got {
+			t.Fatal("ClaimActionCooldown() of another user = false, want true")
+		}
+	})
+
+	t.Run("ClaimActionCooldown concurrently", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		at := now()
+
+		var (
+			wg      sync.WaitGroup
+			claimed atomic.Int32
+		)
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := s.Claimer.ClaimActionCooldown(ctx, user.Id(), entities.ActionPasswordReset, at, time.Minute)
+				if err != nil {
+					t.Errorf("claim action cooldown: %v", err)
+				}
+				if ok {
+					claimed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got := claimed.Load(); got != 1 {
+			t.Fatalf("expected 1 successful claim, got %d", got)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package storagetest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+func requireNoError(t *testing.T, err error, action string) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %v", action, err)
+	}
+}
+
+func requireDuplication(t *testing.T, err error, field string) {
+	t.Helper()
+
+	var duplicationErr *ports.DuplicationError
+	if !errors.As(err, &duplicationErr) {
+		t.Fatalf("expected *ports.DuplicationError on field %q, got %v", field, err)
+	}
+
+	if duplicationErr.Field != field {
+		t.Fatalf("expected duplication on field %q, got %q (%v)", field, duplicationErr.Field, err)
+	}
+}
+
+func requireNotFound(t *testing.T, err error, field string) {
+	t.Helper()
+
+	var notFoundErr *ports.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Fatalf("expected *ports.NotFoundError on field %q, got %v", field, err)
+	}
+
+	if notFoundErr.Field != field {
+		t.Fatalf("expected not found by field %q, got %q (%v)", field, notFoundErr.Field, err)
+	}
+}
+
+func assertUserEqual(t *testing.T, want, got entities.User) {
+	t.Helper()
+
+	if got.Id() != want.Id() {
+		t.Errorf("user id: want %s, got %s", want.Id(), got.Id())
+	}
+	if got.Username() != want.Username() {
+		t.Errorf("username: want %q, got %q", want.Username(), got.Username())
+	}
+	if got.Email() != want.Email() {
+		t.Errorf("email: want %q, got %q", want.Email(), got.Email())
+	}
+	if got.Password() != want.Password() {
+		t.Errorf("password: want %q, got %q", want.Password(), got.Password())
+	}
+	assertTimeEqual(t, "created at", want.CreatedAt(), got.CreatedAt())
+	assertTimeEqual(t, "updated at", want.UpdatedAt(), got.UpdatedAt())
+	assertOptionalTimeEqual(t, "email confirmed at", want.EmailConfirmedAt(), got.EmailConfirmedAt())
+	if got.IsDeleted() != want.IsDeleted() {
+		t.Errorf("is deleted: want %t, got %t", want.IsDeleted(), got.IsDeleted())
+	}
+}
+
+func assertSessionEqual(t *testing.T, want, got entities.Session) {
+	t.Helper()
+
+	if got.Id() != want.Id() {
+		t.Errorf("session id: want %s, got %s", want.Id(), got.Id())
+	}
+	if got.User() != want.User() {
+		t.Errorf("session user: want %s, got %s", want.User(), got.User())
+	}
+	if got.TokenHash() != want.TokenHash() {
+		t.Errorf("session token hash: want %q, got %q", want.TokenHash(), got.TokenHash())
+	}
+	if got.Device() != want.Device() {
+		t.Errorf("session device: want %+v, got %+v", want.Device(), got.Device())
+	}
+	assertTimeEqual(t, "created at", want.CreatedAt(), got.CreatedAt())
+	assertTimeEqual(t, "refreshed at", want.RefreshedAt(), got.RefreshedAt())
+	assertTimeEqual(t, "expires at", want.ExpiresAt(), got.ExpiresAt())
+	assertOptionalTimeEqual(t, "revoked at", want.RevokedAt(), got.RevokedAt())
+}
+
+func assertActionTokenEqual(t *testing.T, want, got entities.ActionToken) {
+	t.Helper()
+
+	if got.Id() != want.Id() {
+		t.Errorf("action token id: want %s, got %s", want.Id(), got.Id())
+	}
+	if got.User() != want.User() {
+		t.Errorf("action token user: want %s, got %s", want.User(), got.User())
+	}
+	if got.Purpose() != want.Purpose() {
+		t.Errorf("action token purpose: want %q, got %q", want.Purpose(), got.Purpose())
+	}
+	if got.TokenHash() != want.TokenHash() {
+		t.Errorf("action token hash: want %q, got %q", want.TokenHash(), got.TokenHash())
+	}
+	assertTimeEqual(t, "created at", want.CreatedAt(), got.CreatedAt())
+	assertTimeEqual(t, "expires at", want.ExpiresAt(), got.ExpiresAt())
+	assertOptionalTimeEqual(t, "used at", want.UsedAt(), got.UsedAt())
+}
+
+// assertTimeEqual compares timestamps at microsecond precision, as entities
+// stamped with time.Now carry nanoseconds that storage may drop.
+func assertTimeEqual(t *testing.T, name string, want, got time.Time) {
+	t.Helper()
+
+	if !got.Truncate(time.Microsecond).Equal(want.Truncate(time.Microsecond)) {
+		t.Errorf("%s: want %s, got %s", name, want, got)
+	}
+}
+
+func assertOptionalTimeEqual(t *testing.T, name string, want, got *time.Time) {
+	t.Helper()
+
+	switch {
+	case want == nil && got == nil:
+	case want == nil || got == nil:
+		t.Errorf("%s: want %v, got %v", name, want, got)
+	default:
+		assertTimeEqual(t, name, *want, *got)
+	}
+}
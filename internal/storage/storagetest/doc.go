@@ -0,0 +1,10 @@
+// Package storagetest contains a conformance suite for implementations of the
+// storage ports declared in internal/application/ports.
+//
+// Every adapter is expected to pass it, which keeps the semantics the
+// application relies on (which field a DuplicationError names, when a
+// NotFoundError is returned, which entity fields survive a round trip)
+// identical across storage backends. Call the Run* functions from the
+// adapter's own tests, passing a constructor that returns fresh, empty
+// storage for every subtest.
+package storagetest
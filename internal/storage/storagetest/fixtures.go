@@ -0,0 +1,148 @@
+package storagetest
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+var fixtureCounter atomic.Int64
+
+// now returns the current time rounded to microseconds, which is the
+// precision Postgres keeps for timestamps.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func newUser() entities.User {
+	n := fixtureCounter.Add(1)
+	created := now().Add(-time.Hour)
+	confirmed := created.Add(time.Minute)
+
+	return entities.LoadUser(
+		uuid.New(),
+		entities.Username(fmt.Sprintf("user%d", n)),
+		entities.Email(fmt.Sprintf("user%d@example.com", n)),
+		entities.RawPassword(fmt.Sprintf("$2a$10$hash%d", n)),
+		created,
+		&confirmed,
+		created.Add(2*time.Minute),
+		false,
+	)
+}
+
+func withUsername(user entities.User, username entities.Username) entities.User {
+	return entities.LoadUser(
+		uuid.New(), username, user.Email()+"x", user.Password(),
+		user.CreatedAt(), user.EmailConfirmedAt(), user.UpdatedAt(), user.IsDeleted(),
+	)
+}
+
+func withEmail(user entities.User, email entities.Email) entities.User {
+	return entities.LoadUser(
+		uuid.New(), user.Username()+"x", email, user.Password(),
+		user.CreatedAt(), user.EmailConfirmedAt(), user.UpdatedAt(), user.IsDeleted(),
+	)
+}
+
+func withUserId(user entities.User, id uuid.UUID) entities.User {
+	return entities.LoadUser(
+		id, user.Username(), user.Email(), user.Password(),
+		user.CreatedAt(), user.EmailConfirmedAt(), user.UpdatedAt(), user.IsDeleted(),
+	)
+}
+
+func withEmailConfirmedAt(user entities.User, confirmedAt *time.Time) entities.User {
+	return entities.LoadUser(
+		user.Id(), user.Username(), user.Email(), user.Password(),
+		user.CreatedAt(), confirmedAt, user.UpdatedAt(), user.IsDeleted(),
+	)
+}
+
+func newSession(user uuid.UUID) entities.Session {
+	n := fixtureCounter.Add(1)
+	created := now().Add(-time.Hour)
+
+	return entities.LoadSession(
+		uuid.New(),
+		user,
+		fmt.Sprintf("token-hash-%d", n),
+		entities.Device{
+			Name:      fmt.Sprintf("device %d", n),
+			UserAgent: "storagetest/1.0",
+			IP:        "192.0.2.1",
+		},
+		created,
+		created.Add(time.Minute),
+		created.Add(24*time.Hour),
+		nil,
+	)
+}
+
+func withSessionToken(session entities.Session, tokenHash string) entities.Session {
+	return entities.LoadSession(
+		session.Id(), session.User(), tokenHash, session.Device(),
+		session.CreatedAt(), session.RefreshedAt(), session.ExpiresAt(), session.RevokedAt(),
+	)
+}
+
+func withSessionId(session entities.Session, id uuid.UUID) entities.Session {
+	return entities.LoadSession(
+		id, session.User(), session.TokenHash(), session.Device(),
+		session.CreatedAt(), session.RefreshedAt(), session.ExpiresAt(), session.RevokedAt(),
+	)
+}
+
+func withSessionExpiry(session entities.Session, expiresAt time.Time) entities.Session {
+	return entities.LoadSession(
+		session.Id(), session.User(), session.TokenHash(), session.Device(),
+		session.CreatedAt(), session.RefreshedAt(), expiresAt, session.RevokedAt(),
+	)
+}
+
+func withSessionCreatedAt(session entities.Session, createdAt time.Time) entities.Session {
+	return entities.LoadSession(
+		session.Id(), session.User(), session.TokenHash(), session.Device(),
+		createdAt, createdAt, session.ExpiresAt(), session.RevokedAt(),
+	)
+}
+
+func newActionToken(user uuid.UUID, purpose entities.ActionPurpose) entities.ActionToken {
+	n := fixtureCounter.Add(1)
+	created := now().Add(-time.Minute)
+
+	return entities.LoadActionToken(
+		uuid.New(),
+		user,
+		purpose,
+		fmt.Sprintf("action-token-hash-%d", n),
+		created,
+		created.Add(time.Hour),
+		nil,
+	)
+}
+
+func withActionTokenTimes(token entities.ActionToken, createdAt, expiresAt time.Time) entities.ActionToken {
+	return entities.LoadActionToken(
+		token.Id(), token.User(), token.Purpose(), token.TokenHash(),
+		createdAt, expiresAt, token.UsedAt(),
+	)
+}
+
+func withActionTokenHash(token entities.ActionToken, tokenHash string) entities.ActionToken {
+	return entities.LoadActionToken(
+		token.Id(), token.User(), token.Purpose(), tokenHash,
+		token.CreatedAt(), token.ExpiresAt(), token.UsedAt(),
+	)
+}
+
+func withActionTokenId(token entities.ActionToken, id uuid.UUID) entities.ActionToken {
+	return entities.LoadActionToken(
+		id, token.User(), token.Purpose(), token.TokenHash(),
+		token.CreatedAt(), token.ExpiresAt(), token.UsedAt(),
+	)
+}
@@ -0,0 +1,398 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// SessionStorage bundles the session ports of a single storage backend.
+// UserAppender is used to create the users sessions belong to, as backends
+// are allowed to enforce that relation.
+type SessionStorage struct {
+	UserAppender ports.UserAppender
+	Appender     ports.SessionAppender
+	Finder       ports.SessionFinder
+	Rotated      ports.RotatedTokenFinder
+	Active       ports.ActiveSessionFinder
+	Rotator      ports.SessionRotator
+	Remover      ports.ExpiredSessionRemover
+	Revoker      ports.SessionRevoker
+}
+
+// RunSessionStorage runs the conformance suite for SessionAppender,
+// SessionFinder, RotatedTokenFinder, ActiveSessionFinder, SessionRotator,
+// SessionRevoker and ExpiredSessionRemover. newStorage is called once per subtest
+// and must return empty storage.
+func RunSessionStorage(t *testing.T, newStorage func(t *testing.T) SessionStorage) {
+	setup := func(t *testing.T) (context.Context, SessionStorage, entities.User) {
+		ctx, s := context.Background(), newStorage(t)
+		user := newUser()
+		requireNoError(t, s.UserAppender.AppendUser(ctx, user), "append user")
+		return ctx, s, user
+	}
+
+	t.Run("Find round-trips every field", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		session := newSession(user.Id())
+		requireNoError(t, s.Appender.AppendSession(ctx, session), "append session")
+
+		got, err := s.Finder.Find(ctx, session.TokenHash())
+		requireNoError(t, err, "find session")
+		assertSessionEqual(t, session, got)
+	})
+
+	t.Run("duplicate token", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		session := newSession(user.Id())
+		requireNoError(t, s.Appender.AppendSession(ctx, session), "append session")
+
+		err := s.Appender.AppendSession(ctx, withSessionToken(newSession(user.Id()), session.TokenHash()))
+		requireDuplication(t, err, "token")
+	})
+
+	t.Run("duplicate id", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		session := newSession(user.Id())
+		requireNoError(t, s.Appender.AppendSession(ctx, session), "append session")
+
+		err := s.Appender.AppendSession(ctx, withSessionId(newSession(user.Id()), session.Id()))
+		requireDuplication(t, err, "id")
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		ctx, s, _ := setup(t)
+
+		_, err := s.Finder.Find(ctx, "missing-"+uuid.NewString())
+		requireNotFound(t, err, "token")
+	})
+
+	t.Run("RotateSession round-trips every field", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		session := newSession(user.Id())
+		requireNoError(t, s.Appender.AppendSession(ctx, session), "append session")
+
+		rotated := session
+		rotated.Refresh("rotated-"+uuid.NewString(), 48*time.Hour)
+		requireNoError(t, s.Rotator.RotateSession(ctx, rotated), "rotate session")
+
+		got, err := s.Finder.Find(ctx, rotated.TokenHash())
+		requireNoError(t, err, "find rotated session")
+		assertSessionEqual(t, rotated, got)
+
+		_, err = s.Finder.Find(ctx, session.TokenHash())
+		requireNotFound(t, err, "token")
+	})
+
+	t.Run("RotateSession records the rotated token", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		session := newSession(user.Id())
+		requireNoError(t, s.Appender.AppendSession(ctx, session), "append session")
+
+		original := session.TokenHash()
+		session.Refresh("rotated-"+uuid.NewString(), time.Hour)
+		requireNoError(t, s.Rotator.RotateSession(ctx, session), "rotate session")
+
+		got, err := s.Rotated.FindByRotatedToken(ctx, original)
+		requireNoError(t, err, "find by rotated token")
+		assertSessionEqual(t, session, got)
+
+		_, err = s.Rotated.FindByRotatedToken(ctx, session.TokenHash())
+		requireNotFound(t, err, "rotated_token")
+	})
+
+	t.Run("every rotated token stays in the lineage", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		session := newSession(user.Id())
+		requireNoError(t, s.Appender.AppendSession(ctx, session), "append session")
+
+		var lineage []string
+		for range 3 {
+			lineage = append(lineage, session.TokenHash())
+			session.Refresh("rotated-"+uuid.NewString(), time.Hour)
+			requireNoError(t, s.Rotator.RotateSession(ctx, session), "rotate session")
+		}
+
+		for _, token := range lineage {
+			got, err := s.Rotated.FindByRotatedToken(ctx, token)
+			requireNoError(t, err, "find by rotated token")
+			if got.Id() != session.Id() {
+				t.Fatalf("rotated token resolved to session %s, want %s", got.Id(), session.Id())
+			}
+		}
+	})
+
+	t.Run("rotated session can be rotated again once loaded", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		session := newSession(user.Id())
+		requireNoError(t, s.Appender.AppendSession(ctx, session), "append session")
+
+		session.Refresh("rotated-"+uuid.NewString(), time.Hour)
+		requireNoError(t, s.Rotator.RotateSession(ctx, session), "rotate session")
+
+		loaded, err := s.Finder.Find(ctx, session.TokenHash())
+		requireNoError(t, err, "find session")
+		if loaded.RotatedTokenHash() != "" {
+			t.Fatalf("loaded session carries rotation state %q", loaded.RotatedTokenHash())
+		}
+
+		loaded.Refresh("rotated-"+uuid.NewString(), time.Hour)
+		requireNoError(t, s.Rotator.RotateSession(ctx, loaded), "rotate loaded session")
+	})
+
+	t.Run("RotateSession from a token rotated away", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		session := newSession(user.Id())
+		requireNoError(t, s.Appender.AppendSession(ctx, session), "append session")
+
+		first, second := session, session
+		first.Refresh("rotated-"+uuid.NewString(), time.Hour)
+		second.Refresh("rotated-"+uuid.NewString(), time.Hour)
+		requireNoError(t, s.Rotator.RotateSession(ctx, first), "rotate session")
+
+		err := s.Rotator.RotateSession(ctx, second)
+		requireNotFound(t, err, "token")
+
+		got, err := s.Finder.Find(ctx, first.TokenHash())
+		requireNoError(t, err, "find session")
+		assertSessionEqual(t, first, got)
+	})
+
+	t.Run("RotateSession of a revoked session", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		session := newSession(user.Id())
+		requireNoError(t, s.Appender.AppendSession(ctx, session), "append session")
+
+		at := now()
+		requireNoError(t, s.Revoker.RevokeSession(ctx, user.Id(), session.Id(), at), "revoke session")
+
+		rotated := session
+		rotated.Refresh("rotated-"+uuid.NewString(), time.Hour)
+		err := s.Rotator.RotateSession(ctx, rotated)
+		requireNotFound(t, err, "token")
+
+		got, err := s.Finder.Find(ctx, session.TokenHash())
+		requireNoError(t, err, "find session")
+		assertOptionalTimeEqual(t, "revoked at", &at, got.RevokedAt())
+	})
+
+	t.Run("unknown rotated token", func(t *testing.T) {
+		ctx, s, _ := setup(t)
+
+		_, err := s.Rotated.FindByRotatedToken(ctx, "missing-"+uuid.NewString())
+		requireNotFound(t, err, "rotated_token")
+	})
+
+	t.Run("FindActiveByUser returns live sessions oldest first", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		other := newUser()
+		requireNoError(t, s.UserAppender.AppendUser(ctx, other), "append other user")
+
+		moment := now()
+		newer := withSessionCreatedAt(newSession(user.Id()), moment.Add(-time.Minute))
+		older := withSessionCreatedAt(newSession(user.Id()), moment.Add(-time.Hour))
+		expired := withSessionExpiry(newSession(user.Id()), moment.Add(-time.Second))
+		revoked := newSession(user.Id())
+		foreign := newSession(other.Id())
+		for _, session := range []entities.Session{newer, older, expired, revoked, foreign} {
+			requireNoError(t, s.Appender.AppendSession(ctx, session), "append session")
+		}
+
+		requireNoError(t, s.Revoker.RevokeSession(ctx, user.Id(), revoked.Id(), moment), "revoke session")
+
+		got, err := s.Active.FindActiveByUser(ctx, user.Id(), moment)
+		requireNoError(t, err, "find active sessions")
+		if len(got) != 2 {
+			t.Fatalf("expected 2 active sessions, got %d", len(got))
+		}
+		assertSessionEqual(t, older, got[0])
+		assertSessionEqual(t, newer, got[1])
+	})
+
+	t.Run("FindActiveByUser without sessions", func(t *testing.T) {
+		ctx, s, user := setup(t)
+
+		got, err := s.Active.FindActiveByUser(ctx, user.Id(), now())
+		requireNoError(t, err, "find active sessions")
+		if len(got) != 0 {
+			t.Fatalf("expected no active sessions, got %d", len(got))
+		}
+	})
+
+	t.Run("RevokeSession revokes only the given session", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		target, other := newSession(user.Id()), newSession(user.Id())
+		requireNoError(t, s.Appender.AppendSession(ctx, target), "append target session")
+		requireNoError(t, s.Appender.AppendSession(ctx, other), "append other session")
+
+		at := now()
+		requireNoError(t, s.Revoker.RevokeSession(ctx, user.Id(), target.Id(), at), "revoke session")
+
+		got, err := s.Finder.Find(ctx, target.TokenHash())
+		requireNoError(t, err, "find revoked session")
+		assertOptionalTimeEqual(t, "revoked at", &at, got.RevokedAt())
+
+		got, err = s.Finder.Find(ctx, other.TokenHash())
+		requireNoError(t, err, "find other session")
+		assertSessionEqual(t, other, got)
+	})
+
+	t.Run("RevokeSession of another user's session", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		other := newUser()
+		requireNoError(t, s.UserAppender.AppendUser(ctx, other), "append other user")
+		session := newSession(other.Id())
+		requireNoError(t, s.Appender.AppendSession(ctx, session), "append session")
+
+		err := s.Revoker.RevokeSession(ctx, user.Id(), session.Id(), now())
+		requireNotFound(t, err, "id")
+
+		got, err := s.Finder.Find(ctx, session.TokenHash())
+		requireNoError(t, err, "find session")
+		assertSessionEqual(t, session, got)
+	})
+
+	t.Run("RevokeSession of a revoked session", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		session := newSession(user.Id())
+		requireNoError(t, s.Appender.AppendSession(ctx, session), "append session")
+		requireNoError(t, s.Revoker.RevokeSession(ctx, user.Id(), session.Id(), now()), "revoke session")
+
+		err := s.Revoker.RevokeSession(ctx, user.Id(), session.Id(), now())
+		requireNotFound(t, err, "id")
+	})
+
+	t.Run("RevokeUserSessions revokes every live session of the user", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		other := newUser()
+		requireNoError(t, s.UserAppender.AppendUser(ctx, other), "append other user")
+
+		for range 2 {
+			requireNoError(t, s.Appender.AppendSession(ctx, newSession(user.Id())), "append session")
+		}
+		foreign := newSession(other.Id())
+		requireNoError(t, s.Appender.AppendSession(ctx, foreign), "append foreign session")
+
+		revoked, err := s.Revoker.RevokeUserSessions(ctx, user.Id(), now())
+		requireNoError(t, err, "revoke user sessions")
+		if revoked != 2 {
+			t.Fatalf("expected 2 revoked sessions, got %d", revoked)
+		}
+
+		active, err := s.Active.FindActiveByUser(ctx, user.Id(), now())
+		requireNoError(t, err, "find active sessions")
+		if len(active) != 0 {
+			t.Fatalf("expected no active sessions, got %d", len(active))
+		}
+
+		active, err = s.Active.FindActiveByUser(ctx, other.Id(), now())
+		requireNoError(t, err, "find foreign active sessions")
+		if len(active) != 1 {
+			t.Fatalf("expected foreign session to stay active, got %d", len(active))
+		}
+	})
+
+	t.Run("RevokeOtherSessions keeps the given session", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		other := newUser()
+		requireNoError(t, s.UserAppender.AppendUser(ctx, other), "append other user")
+
+		current := newSession(user.Id())
+		requireNoError(t, s.Appender.AppendSession(ctx, current), "append current session")
+		for range 2 {
+			requireNoError(t, s.Appender.AppendSession(ctx, newSession(user.Id())), "append session")
+		}
+		requireNoError(t, s.Appender.AppendSession(ctx, newSession(other.Id())), "append foreign session")
+
+		revoked, err := s.Revoker.RevokeOtherSessions(ctx, user.Id(), current.Id(), now())
+		requireNoError(t, err, "revoke other sessions")
+		if revoked != 2 {
+			t.Fatalf("expected 2 revoked sessions, got %d", revoked)
+		}
+
+		active, err := s.Active.FindActiveByUser(ctx, user.Id(), now())
+		requireNoError(t, err, "find active sessions")
+		if len(active) != 1 || active[0].Id() != current.Id() {
+			t.Fatalf("expected only the current session to stay active, got %d", len(active))
+		}
+
+		active, err = s.Active.FindActiveByUser(ctx, other.Id(), now())
+		requireNoError(t, err, "find foreign active sessions")
+		if len(active) != 1 {
+			t.Fatalf("expected foreign session to stay active, got %d", len(active))
+		}
+	})
+
+	t.Run("RotateSession to a token in use", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		first, second := newSession(user.Id()), newSession(user.Id())
+		requireNoError(t, s.Appender.AppendSession(ctx, first), "append first session")
+		requireNoError(t, s.Appender.AppendSession(ctx, second), "append second session")
+
+		rotated := second
+		rotated.Refresh(first.TokenHash(), time.Hour)
+		err := s.Rotator.RotateSession(ctx, rotated)
+		requireDuplication(t, err, "token")
+
+		got, err := s.Finder.Find(ctx, second.TokenHash())
+		requireNoError(t, err, "find second session")
+		assertSessionEqual(t, second, got)
+	})
+
+	t.Run("RotateSession of an unknown session", func(t *testing.T) {
+		ctx, s, user := setup(t)
+
+		session := newSession(user.Id())
+		session.Refresh("rotated-"+uuid.NewString(), time.Hour)
+		err := s.Rotator.RotateSession(ctx, session)
+		requireNotFound(t, err, "token")
+	})
+
+	t.Run("RemoveExpiredSessions deletes only expired sessions", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		moment := now()
+		expired := withSessionExpiry(newSession(user.Id()), moment.Add(-time.Minute))
+		active := withSessionExpiry(newSession(user.Id()), moment.Add(time.Minute))
+		requireNoError(t, s.Appender.AppendSession(ctx, expired), "append expired session")
+		requireNoError(t, s.Appender.AppendSession(ctx, active), "append active session")
+
+		removed, err := s.Remover.RemoveExpiredSessions(ctx, moment, 10)
+		requireNoError(t, err, "remove expired sessions")
+		if removed != 1 {
+			t.Fatalf("expected 1 removed session, got %d", removed)
+		}
+
+		_, err = s.Finder.Find(ctx, expired.TokenHash())
+		requireNotFound(t, err, "token")
+
+		got, err := s.Finder.Find(ctx, active.TokenHash())
+		requireNoError(t, err, "find active session")
+		assertSessionEqual(t, active, got)
+	})
+
+	t.Run("RemoveExpiredSessions respects the limit", func(t *testing.T) {
+		ctx, s, user := setup(t)
+		moment := now()
+		for range 3 {
+			session := withSessionExpiry(newSession(user.Id()), moment.Add(-time.Minute))
+			requireNoError(t, s.Appender.AppendSession(ctx, session), "append expired session")
+		}
+
+		removed, err := s.Remover.RemoveExpiredSessions(ctx, moment, 2)
+		requireNoError(t, err, "remove expired sessions")
+		if removed != 2 {
+			t.Fatalf("expected 2 removed sessions, got %d", removed)
+		}
+
+		removed, err = s.Remover.RemoveExpiredSessions(ctx, moment, 2)
+		requireNoError(t, err, "remove remaining expired sessions")
+		if removed != 1 {
+			t.Fatalf("expected 1 removed session, got %d", removed)
+		}
+	})
+}
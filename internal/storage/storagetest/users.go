@@ -0,0 +1,209 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/application/ports"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// UserStorage bundles the user ports of a single storage backend. All of
+// them must operate on the same underlying data.
+type UserStorage struct {
+	Appender ports.UserAppender
+	Finder   ports.UserFinder
+	Updater  ports.UserUpdater
+}
+
+// RunUserStorage runs the conformance suite for UserAppender, UserFinder and
+// UserUpdater.
+// newStorage is called once per subtest and must return empty storage.
+func RunUserStorage(t *testing.T, newStorage func(t *testing.T) UserStorage) {
+	t.Run("FindById round-trips every field", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+		user := newUser()
+		requireNoError(t, s.Appender.AppendUser(ctx, user), "append user")
+
+		got, err := s.Finder.FindById(ctx, user.Id())
+		requireNoError(t, err, "find by id")
+		assertUserEqual(t, user, got)
+	})
+
+	t.Run("FindByUsername round-trips every field", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+		user := newUser()
+		requireNoError(t, s.Appender.AppendUser(ctx, user), "append user")
+
+		got, err := s.Finder.FindByUsername(ctx, user.Username())
+		requireNoError(t, err, "find by username")
+		assertUserEqual(t, user, got)
+	})
+
+	t.Run("FindByEmail round-trips every field", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+		user := newUser()
+		requireNoError(t, s.Appender.AppendUser(ctx, user), "append user")
+
+		got, err := s.Finder.FindByEmail(ctx, user.Email())
+		requireNoError(t, err, "find by email")
+		assertUserEqual(t, user, got)
+	})
+
+	t.Run("optional and default fields round-trip", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+		base := newUser()
+		user := entities.LoadUser(
+			base.Id(), base.Username(), base.Email(), base.Password(),
+			base.CreatedAt(), nil, base.UpdatedAt().Add(time.Hour), true,
+		)
+		requireNoError(t, s.Appender.AppendUser(ctx, user), "append user")
+
+		got, err := s.Finder.FindByUsername(ctx, user.Username())
+		requireNoError(t, err, "find by username")
+		assertUserEqual(t, user, got)
+	})
+
+	t.Run("duplicate username", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+		user := newUser()
+		requireNoError(t, s.Appender.AppendUser(ctx, user), "append user")
+
+		err := s.Appender.AppendUser(ctx, withUsername(user, user.Username()))
+		requireDuplication(t, err, "username")
+	})
+
+	t.Run("duplicate email", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+		user := newUser()
+		requireNoError(t, s.Appender.AppendUser(ctx, user), "append user")
+
+		err := s.Appender.AppendUser(ctx, withEmail(user, user.Email()))
+		requireDuplication(t, err, "email")
+	})
+
+	t.Run("duplicate id", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+		user := newUser()
+		requireNoError(t, s.Appender.AppendUser(ctx, user), "append user")
+
+		err := s.Appender.AppendUser(ctx, withUserId(newUser(), user.Id()))
+		requireDuplication(t, err, "id")
+	})
+
+	t.Run("failed append leaves storage untouched", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+		user := newUser()
+		requireNoError(t, s.Appender.AppendUser(ctx, user), "append user")
+
+		clash := withUsername(user, user.Username())
+		requireDuplication(t, s.Appender.AppendUser(ctx, clash), "username")
+
+		_, err := s.Finder.FindByEmail(ctx, clash.Email())
+		requireNotFound(t, err, "email")
+	})
+
+	t.Run("unknown id", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+
+		_, err := s.Finder.FindById(ctx, uuid.New())
+		requireNotFound(t, err, "id")
+	})
+
+	t.Run("unknown username", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+
+		_, err := s.Finder.FindByUsername(ctx, entities.Username("missing-"+uuid.NewString()))
+		requireNotFound(t, err, "username")
+	})
+
+	t.Run("unknown email", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+
+		_, err := s.Finder.FindByEmail(ctx, entities.Email(uuid.NewString()+"@example.com"))
+		requireNotFound(t, err, "email")
+	})
+
+	t.Run("ConfirmEmail confirms an unconfirmed user", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+		user := withEmailConfirmedAt(newUser(), nil)
+		requireNoError(t, s.Appender.AppendUser(ctx, user), "append user")
+
+		at := now()
+		requireNoError(t, s.Updater.ConfirmEmail(ctx, user.Id(), at), "confirm email")
+
+		got, err := s.Finder.FindById(ctx, user.Id())
+		requireNoError(t, err, "find by id")
+		assertOptionalTimeEqual(t, "email confirmed at", &at, got.EmailConfirmedAt())
+		assertTimeEqual(t, "updated at", at, got.UpdatedAt())
+		if got.Password() != user.Password() {
+			t.Errorf("password: want %q, got %q", user.Password(), got.Password())
+		}
+	})
+
+	t.Run("ConfirmEmail keeps the first confirmation", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+		user := newUser()
+		requireNoError(t, s.Appender.AppendUser(ctx, user), "append user")
+
+		requireNoError(t, s.Updater.ConfirmEmail(ctx, user.Id(), now()), "confirm email")
+
+		got, err := s.Finder.FindById(ctx, user.Id())
+		requireNoError(t, err, "find by id")
+		assertUserEqual(t, user, got)
+	})
+
+	t.Run("ConfirmEmail of unknown user", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+
+		err := s.Updater.ConfirmEmail(ctx, uuid.New(), now())
+		requireNotFound(t, err, "id")
+	})
+
+	t.Run("UpdatePassword replaces the current hash", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+		user := newUser()
+		requireNoError(t, s.Appender.AppendUser(ctx, user), "append user")
+
+		updated := entities.RawPassword("$2a$10$other")
+		requireNoError(t, s.Updater.UpdatePassword(ctx, user.Id(), user.Password(), updated), "update password")
+
+		got, err := s.Finder.FindById(ctx, user.Id())
+		requireNoError(t, err, "find by id")
+		if got.Password() != updated {
+			t.Fatalf("password: want %q, got %q", updated, got.Password())
+		}
+		if !got.UpdatedAt().After(user.UpdatedAt()) {
+			t.Errorf("updated at: want after %s, got %s", user.UpdatedAt(), got.UpdatedAt())
+		}
+	})
+
+	t.Run("UpdatePassword from a stale hash", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+		user := newUser()
+		requireNoError(t, s.Appender.AppendUser(ctx, user), "append user")
+
+		changed := entities.RawPassword("$2a$10$changed")
+		requireNoError(t, s.Updater.UpdatePassword(ctx, user.Id(), user.Password(), changed), "update password")
+
+		err := s.Updater.UpdatePassword(ctx, user.Id(), user.Password(), entities.RawPassword("$2a$10$stale"))
+		requireNotFound(t, err, "password")
+
+		got, err := s.Finder.FindById(ctx, user.Id())
+		requireNoError(t, err, "find by id")
+		if got.Password() != changed {
+			t.Fatalf("password: want %q, got %q", changed, got.Password())
+		}
+	})
+
+	t.Run("UpdatePassword of unknown user", func(t *testing.T) {
+		ctx, s := context.Background(), newStorage(t)
+		user := newUser()
+
+		err := s.Updater.UpdatePassword(ctx, user.Id(), user.Password(), entities.RawPassword("$2a$10$other"))
+		requireNotFound(t, err, "password")
+	})
+}
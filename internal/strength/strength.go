@@ -0,0 +1,437 @@
+// Package strength estimates how hard passwords are to guess in the manner of
+// zxcvbn, with zxcvbn's frequency lists embedded.
+package strength
+
+import (
+	"embed"
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// maxEstimatedLength bounds the work Estimate does. Characters past
+// it are counted as brute force, which long passwords earn anyway.
+const maxEstimatedLength = 100
+
+// Parts of a password shorter than the whole are assumed to take at least
+// this many guesses, so splitting it into many tiny matches does not pay.
+const (
+	minSingleCharGuesses = 10
+	minMultiCharGuesses  = 50
+)
+
+// maxDictionaryWordLength bounds the substrings looked up in dictionaries.
+const maxDictionaryWordLength = 32
+
+// The dictionaries are zxcvbn's frequency lists, most common word first:
+// leaked passwords, English words, first names and surnames.
+//
+//go:embed dictionaries/*.txt
+var dictionaryFiles embed.FS
+
+// frequencyLists are loaded on first use, as they are large and most
+// programs importing this package never estimate a password.
+var frequencyLists = sync.OnceValue(func() []map[string]int {
+	return loadDictionaries("passwords.txt", "english.txt", "female_names.txt", "male_names.txt", "surnames.txt")
+})
+
+var l33tSubstitutions = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g', '1': 'i',
+	'!': 'i', '|': 'l', '0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+var keyboardRows = []string{"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./"}
+
+// Estimator implements entities.PasswordStrengthEstimator.
+type Estimator struct{}
+
+var _ entities.PasswordStrengthEstimator = Estimator{}
+
+// EstimateStrength implements entities.PasswordStrengthEstimator with
+// Estimate.
+func (Estimator) EstimateStrength(password string, userInputs ...string) int {
+	return Estimate(password, userInputs...)
+}
+
+// Estimate scores how hard password is to guess, from 0, within a thousand
+// guesses, to 4, beyond ten billion. Like zxcvbn it finds the cheapest way to
+// cover the password with dictionary words, sequences, repeats, keyboard
+// runs, years and brute-forced characters. userInputs, such as the username,
+// rank as the most common words of all.
+//
+// The score is a rough estimate. The matchers are simpler than zxcvbn's and
+// words missing from the dictionaries are brute-forced, so passwords built
+// around misspellings or rare words can score higher than zxcvbn rates them.
+func Estimate(password string, userInputs ...string) int {
+	return newEstimator(time.Now().Year(), userInputs).score(password)
+}
+
+type estimator struct {
+	dictionaries []map[string]int
+	// year is the present, which years in passwords are measured from.
+	year int
+	memo map[string]float64
+}
+
+func newEstimator(year int, userInputs []string) *estimator {
+	return &estimator{
+		dictionaries: append([]map[string]int{rankedDictionary(userInputs)}, frequencyLists()...),
+		year:         year,
+		memo:         make(map[string]float64),
+	}
+}
+
+func (e *estimator) score(password string) int {
+	runes := []rune(password)
+	extra := 0
+	if len(runes) > maxEstimatedLength {
+		extra = len(runes) - maxEstimatedLength
+		runes = runes[:maxEstimatedLength]
+	}
+
+	guesses := e.log10Guesses(runes) + float64(extra)
+
+	switch {
+	case guesses < 3:
+		return 0
+	case guesses < 6:
+		return 1
+	case guesses < 8:
+		return 2
+	case guesses < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func loadDictionaries(names ...string) []map[string]int {
+	dictionaries := make([]map[string]int, len(names))
+	for i, name := range names {
+		words, err := dictionaryFiles.ReadFile("dictionaries/" + name)
+		if err != nil {
+			panic(err)
+		}
+		dictionaries[i] = rankedDictionary(strings.Split(string(words), "\n"))
+	}
+	return dictionaries
+}
+
+func rankedDictionary(words []string) map[string]int {
+	ranks := make(map[string]int, len(words))
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if _, ok := ranks[word]; word != "" && !ok {
+			ranks[word] = len(ranks) + 1
+		}
+	}
+	return ranks
+}
+
+// match covers password[start:end+1] and takes 10^log10 guesses.
+type match struct {
+	start int
+	log10 float64
+}
+
+// log10Guesses returns the base 10 logarithm of the guesses needed for
+// password, following zxcvbn's estimate for a sequence of l matches:
+// l! times the product of their guesses, plus 10000^(l-1).
+func (e *estimator) log10Guesses(password []rune) float64 {
+	n := len(password)
+	if n == 0 {
+		return 0
+	}
+	if guesses, ok := e.memo[string(password)]; ok {
+		return guesses
+	}
+
+	byEnd := make([][]match, n)
+	add := func(start, end int, guesses float64) {
+		if end-start+1 < n {
+			floor := math.Log10(minMultiCharGuesses)
+			if start == end {
+				floor = math.Log10(minSingleCharGuesses)
+			}
+			guesses = max(guesses, floor)
+		}
+		byEnd[end] = append(byEnd[end], match{start: start, log10: guesses})
+	}
+
+	for start := range n {
+		for end := start; end < n; end++ {
+			add(start, end, float64(end-start+1))
+		}
+	}
+	e.dictionaryMatches(password, add)
+	e.repeatMatches(password, add)
+	sequenceMatches(password, add)
+	keyboardMatches(password, add)
+	e.yearMatches(password, add)
+
+	// best[end][l] is the cheapest cover of password[:end+1] by l matches.
+	inf := math.Inf(1)
+	best := make([][]float64, n)
+	for end := range best {
+		best[end] = make([]float64, n+1)
+		for l := range best[end] {
+			best[end][l] = inf
+		}
+	}
+	for end, matches := range byEnd {
+		for _, m := range matches {
+			if m.start == 0 {
+				best[end][1] = min(best[end][1], m.log10)
+				continue
+			}
+			for l, prev := range best[m.start-1] {
+				if prev < inf && l < n {
+					best[end][l+1] = min(best[end][l+1], prev+m.log10)
+				}
+			}
+		}
+	}
+
+	guesses := inf
+	for l, sum := range best[n-1] {
+		if sum == inf {
+			continue
+		}
+		lgamma, _ := math.Lgamma(float64(l + 1))
+		guesses = min(guesses, log10Add(lgamma/math.Ln10+sum, 4*float64(l-1)))
+	}
+
+	e.memo[string(password)] = guesses
+	return guesses
+}
+
+func (e *estimator) dictionaryMatches(password []rune, add func(start, end int, guesses float64)) {
+	lower := make([]rune, len(password))
+	unl33t := make([]rune, len(password))
+	for i, r := range password {
+		lower[i] = unicode.ToLower(r)
+		unl33t[i] = lower[i]
+		if sub, ok := l33tSubstitutions[lower[i]]; ok {
+			unl33t[i] = sub
+		}
+	}
+
+	for start := range password {
+		for end := start; end < len(password) && end-start < maxDictionaryWordLength; end++ {
+			word := password[start : end+1]
+			variations := math.Log10(uppercaseVariations(word))
+
+			if rank, ok := e.rank(string(lower[start : end+1])); ok {
+				add(start, end, math.Log10(float64(rank))+variations)
+			}
+			if rank, ok := e.rank(reversed(lower[start : end+1])); ok {
+				add(start, end, math.Log10(float64(rank))+variations+math.Log10(2))
+			}
+
+			substituted := 0
+			for i := start; i <= end; i++ {
+				if unl33t[i] != lower[i] {
+					substituted++
+				}
+			}
+			if substituted == 0 {
+				continue
+			}
+			if rank, ok := e.rank(string(unl33t[start : end+1])); ok {
+				add(start, end, math.Log10(float64(rank))+variations+float64(substituted)*math.Log10(2))
+			}
+		}
+	}
+}
+
+// rank returns the best rank of word in any dictionary.
+func (e *estimator) rank(word string) (int, bool) {
+	best, found := 0, false
+	for _, dictionary := range e.dictionaries {
+		if rank, ok := dictionary[word]; ok && (!found || rank < best) {
+			best, found = rank, true
+		}
+	}
+	return best, found
+}
+
+// uppercaseVariations counts the ways word could have been capitalised,
+// with the common all-caps and first or last letter forms counting twice.
+func uppercaseVariations(word []rune) float64 {
+	upper, lower := 0, 0
+	for _, r := range word {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+	if lower == 0 || (upper == 1 && (unicode.IsUpper(word[0]) || unicode.IsUpper(word[len(word)-1]))) {
+		return 2
+	}
+
+	variations := 0.0
+	for i := 1; i <= min(upper, lower); i++ {
+		variations += binomial(upper+lower, i)
+	}
+	return variations
+}
+
+// repeatMatches finds a block repeated back to back, which is guessed by
+// guessing the block and the number of repeats.
+func (e *estimator) repeatMatches(password []rune, add func(start, end int, guesses float64)) {
+	for start := range password {
+		for size := 1; start+2*size <= len(password); size++ {
+			block := password[start : start+size]
+			if !primitive(block) {
+				continue
+			}
+
+			count := 1
+			for next := start + size; next+size <= len(password) && string(password[next:next+size]) == string(block); next += size {
+				count++
+			}
+			if count < 2 || (size == 1 && count < 3) {
+				continue
+			}
+			add(start, start+size*count-1, e.log10Guesses(block)+math.Log10(float64(count)))
+		}
+	}
+}
+
+// primitive reports whether block is not itself a smaller block repeated.
+func primitive(block []rune) bool {
+	for size := 1; size <= len(block)/2; size++ {
+		if len(block)%size != 0 {
+			continue
+		}
+		if strings.Repeat(string(block[:size]), len(block)/size) == string(block) {
+			return false
+		}
+	}
+	return true
+}
+
+// sequenceMatches finds letters or digits stepping by a constant small
+// amount, such as "abc", "13579" or "zyx".
+func sequenceMatches(password []rune, add func(start, end int, guesses float64)) {
+	sameKind := func(a, b rune) bool {
+		return (unicode.IsDigit(a) && unicode.IsDigit(b)) ||
+			(unicode.IsLower(a) && unicode.IsLower(b)) ||
+			(unicode.IsUpper(a) && unicode.IsUpper(b))
+	}
+
+	for start := 0; start < len(password)-2; {
+		delta := password[start+1] - password[start]
+		end := start + 1
+		for end+1 < len(password) && password[end+1]-password[end] == delta && sameKind(password[end], password[end+1]) {
+			end++
+		}
+		if delta == 0 || delta > 5 || delta < -5 || !sameKind(password[start], password[start+1]) || end-start < 2 {
+			start++
+			continue
+		}
+
+		base := 26.0
+		switch first := password[start]; {
+		case strings.ContainsRune("aAzZ019", first):
+			base = 4
+		case unicode.IsDigit(first):
+			base = 10
+		}
+		if delta < 0 {
+			base *= 2
+		}
+		add(start, end, math.Log10(base*float64(end-start+1)))
+		start = end
+	}
+}
+
+// keyboardMatches finds runs of adjacent keys along a row of a US keyboard,
+// such as "qwerty" or "lkjh".
+func keyboardMatches(password []rune, add func(start, end int, guesses float64)) {
+	keys := 0
+	for _, row := range keyboardRows {
+		keys += len(row)
+	}
+	adjacent := func(a, b rune, step int) bool {
+		a, b = unicode.ToLower(a), unicode.ToLower(b)
+		for _, row := range keyboardRows {
+			i := strings.IndexRune(row, a)
+			if i >= 0 && i+step >= 0 && i+step < len(row) && rune(row[i+step]) == b {
+				return true
+			}
+		}
+		return false
+	}
+
+	for start := 0; start < len(password)-2; {
+		end := start
+		for _, step := range []int{1, -1} {
+			e := start
+			for e+1 < len(password) && adjacent(password[e], password[e+1], step) {
+				e++
+			}
+			end = max(end, e)
+		}
+		if end-start < 2 {
+			start++
+			continue
+		}
+		add(start, end, math.Log10(float64(2*keys*(end-start+1))))
+		start = end
+	}
+}
+
+// yearMatches finds recent years, which are guessed by their distance from
+// the present.
+func (e *estimator) yearMatches(password []rune, add func(start, end int, guesses float64)) {
+	for start := 0; start+4 <= len(password); start++ {
+		year := 0
+		for _, r := range password[start : start+4] {
+			if r < '0' || r > '9' {
+				year = -1
+				break
+			}
+			year = 10*year + int(r-'0')
+		}
+		if year < 1900 || year > 2099 {
+			continue
+		}
+		distance := year - e.year
+		if distance < 0 {
+			distance = -distance
+		}
+		add(start, start+3, math.Log10(float64(max(distance, 20))))
+	}
+}
+
+func reversed(word []rune) string {
+	out := make([]rune, len(word))
+	for i, r := range word {
+		out[len(word)-1-i] = r
+	}
+	return string(out)
+}
+
+func binomial(n, k int) float64 {
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+	return result
+}
+
+// log10Add returns log10(10^a + 10^b).
+func log10Add(a, b float64) float64 {
+	hi, lo := max(a, b), min(a, b)
+	return hi + math.Log10(1+math.Pow(10, lo-hi))
+}
@@ -0,0 +1,72 @@
+package strength
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		password   string
+		userInputs []string
+		want       int
+	}{
+		// The examples zxcvbn's README scores.
+		{"zxcvbn", nil, 0},
+		{"Tr0ub4dour&3", nil, 2},
+		{"correcthorsebatterystaple", nil, 4},
+		{"coRrecth0rseba++ery9.23.2007staple$", nil, 4},
+
+		{"password", nil, 0},
+		{"123456", nil, 0},
+		{"qwertyuiop", nil, 0},
+		{"1qaz2wsx", nil, 0},
+		{"aaaaaaaaaa", nil, 0},
+		{"abcdefgh", nil, 0},
+		{"drowssap", nil, 0},
+		{"P@ssw0rd", nil, 0},
+		{"Password1", nil, 0},
+		{"letmein!", nil, 1},
+		{"monkey123", nil, 1},
+		{"alice1990", []string{"alice"}, 1},
+		{"kX9#vQ2!mZ7$wL4p", nil, 4},
+	}
+
+	for _, tt := range tests {
+		if got := Estimate(tt.password, tt.userInputs...); got != tt.want {
+			t.Errorf("Estimate(%q, %q) = %d, want %d", tt.password, tt.userInputs, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateRanksUserInputsFirst(t *testing.T) {
+	const password = "quillmoxen-zarvik"
+	if with, without := Estimate(password, "quillmoxen", "zarvik"), Estimate(password); with >= without {
+		t.Errorf("Estimate(%q) with user inputs = %d, want below %d without", password, with, without)
+	}
+}
+
+func TestYearMatchesMeasureFromPresent(t *testing.T) {
+	tests := []struct {
+		present  int
+		password string
+		want     float64
+	}{
+		{2026, "2026", math.Log10(20)},
+		{2026, "2016", math.Log10(20)},
+		{2026, "1950", math.Log10(76)},
+		{2026, "2090", math.Log10(64)},
+		{2090, "2090", math.Log10(20)},
+		{2090, "2026", math.Log10(64)},
+	}
+
+	for _, tt := range tests {
+		var got []float64
+		newEstimator(tt.present, nil).yearMatches([]rune(tt.password), func(start, end int, guesses float64) {
+			got = append(got, guesses)
+		})
+		if len(got) != 1 || got[0] != tt.want {
+			t.Errorf("yearMatches(%q) in %d = %v, want [%v]", tt.password, tt.present, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/application"
+)
+
+// authenticate resolves the user behind the bearer access token of the
+// request.
+func (h *Handler) authenticate(r *http.Request) (uuid.UUID, error) {
+	accessToken, err := h.accessToken(r)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return accessToken.Subject, nil
+}
+
+// accessToken verifies the bearer access token of the request and returns
+// its claims, for handlers that need more than the user, such as the session.
+// Tokens of logged out or revoked sessions are refused.
+func (h *Handler) accessToken(r *http.Request) (application.AccessToken, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return application.AccessToken{}, application.ErrInvalidToken
+	}
+
+	return h.sessionService.VerifyActiveAccessToken(r.Context(), token)
+}
@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"net/http"
+)
+
+type resendConfirmationRequest struct {
+	Email string `json:"email"`
+}
+
+type confirmEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// resendConfirmation always answers 202, whether or not a mail was sent, so
+// it cannot be used to probe for registered addresses.
+func (h *Handler) resendConfirmation(w http.ResponseWriter, r *http.Request) {
+	var req resendConfirmationRequest
+	if err := h.decode(w, r, &req); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	h.confirmationService.ResendConfirmation(r.Context(), req.Email, h.locale(r))
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) confirmEmail(w http.ResponseWriter, r *http.Request) {
+	var req confirmEmailRequest
+	if err := h.decode(w, r, &req); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	if err := h.confirmationService.ConfirmEmail(r.Context(), req.Token); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,145 @@
+package rest
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/maxdikun/users-api/internal/application"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+// problem is an RFC 9457 problem details object. Code is a stable,
+// machine-readable identifier of the problem and Errors lists the offending
+// fields of a validation failure.
+type problem struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+	Code   string       `json:"code"`
+	Errors []fieldError `json:"errors,omitempty"`
+}
+
+type fieldError struct {
+	Field string `json:"field"`
+	// Pointer is a JSON pointer to the field within the request body.
+	Pointer string                    `json:"pointer"`
+	Code    entities.ValidationCode   `json:"code"`
+	Detail  string                    `json:"detail"`
+	Params  entities.ValidationParams `json:"params,omitempty"`
+}
+
+// fail answers with the problem err stands for, translated into the locale
+// the client asked for.
+func (h *Handler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	locale := h.locale(r)
+
+	if vErrs := entities.ValidationErrors(err); len(vErrs) > 0 {
+		fields := make([]fieldError, 0, len(vErrs))
+		for _, vErr := range vErrs {
+			fields = append(fields, fieldError{
+				Field:   vErr.Field,
+				Pointer: "#/" + strings.ReplaceAll(vErr.Field, ".", "/"),
+				Code:    vErr.Code,
+				Detail:  h.translateValidation(locale, vErr),
+				Params:  vErr.Params,
+			})
+		}
+
+		h.respondProblem(w, r, locale, problem{
+			Status: http.StatusUnprocessableEntity,
+			Detail: "request validation failed",
+			Code:   "validation_failed",
+			Errors: fields,
+		})
+		return
+	}
+
+	status, code := http.StatusInternalServerError, "internal"
+	switch {
+	case errors.Is(err, errMalformedBody):
+		status, code = http.StatusBadRequest, "malformed_body"
+	case errors.Is(err, errClientUnauthorized):
+		status, code = http.StatusUnauthorized, "invalid_client"
+	case errors.Is(err, application.ErrInvalidCredentials):
+		status, code = http.StatusUnauthorized, "invalid_credentials"
+	case errors.Is(err, application.ErrIncorrectPassword):
+		status, code = http.StatusForbidden, "incorrect_password"
+	case errors.Is(err, application.ErrEmailNotConfirmed):
+		status, code = http.StatusForbidden, "email_not_confirmed"
+	case errors.Is(err, application.ErrInvalidActionToken):
+		status, code = http.StatusBadRequest, "invalid_action_token"
+	case errors.Is(err, application.ErrUsernameTaken):
+		status, code = http.StatusConflict, "username_taken"
+	case errors.Is(err, application.ErrEmailTaken):
+		status, code = http.StatusConflict, "email_taken"
+	case errors.Is(err, application.ErrInvalidToken):
+		status, code = http.StatusUnauthorized, "invalid_token"
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	case errors.Is(err, application.ErrSessionNotFound):
+		status, code = http.StatusNotFound, "session_not_found"
+	}
+
+	detail := err.Error()
+	if status == http.StatusInternalServerError {
+		h.logger.ErrorContext(r.Context(), "Request failed", slog.Any("error", err))
+		detail = application.ErrInternal.Error()
+	}
+
+	h.respondProblem(w, r, locale, problem{
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	})
+}
+
+// respondProblem writes p as application/problem+json, with its detail
+// translated into locale when the catalog knows its code. Problems are not
+// documented at a URL of their own, so the type is about:blank and the
+// title the status text, as RFC 9457 prescribes for that case.
+func (h *Handler) respondProblem(w http.ResponseWriter, r *http.Request, locale string, p problem) {
+	p.Type = "about:blank"
+	p.Title = http.StatusText(p.Status)
+	if detail, ok := h.catalog.Translate(locale, "problem."+p.Code, nil); ok {
+		p.Detail = detail
+	}
+
+	w.Header().Set("Content-Language", locale)
+	w.Header().Add("Vary", "Accept-Language")
+	h.write(w, r, p.Status, "application/problem+json", p)
+}
+
+// translateValidation looks vErr up by field and code, then by code alone,
+// and falls back to its own message.
+func (h *Handler) translateValidation(locale string, vErr *entities.ValidationError) string {
+	for _, key := range []string{
+		"validation." + vErr.Field + "." + string(vErr.Code),
+		"validation." + string(vErr.Code),
+	} {
+		if message, ok := h.catalog.Translate(locale, key, vErr.Params); ok {
+			return message
+		}
+	}
+	return vErr.Message
+}
+
+// renameField moves the validation errors of field to rename, for request
+// bodies that name an input differently than the entities do.
+func renameField(err error, field, rename string) error {
+	vErrs := entities.ValidationErrors(err)
+	if len(vErrs) == 0 {
+		return err
+	}
+
+	renamed := make([]error, 0, len(vErrs))
+	for _, vErr := range vErrs {
+		vErr := *vErr
+		if vErr.Field == field {
+			vErr.Field = rename
+		}
+		renamed = append(renamed, &vErr)
+	}
+	return errors.Join(renamed...)
+}
@@ -0,0 +1,88 @@
+package rest
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/maxdikun/users-api/internal/application"
+	"github.com/maxdikun/users-api/internal/i18n"
+)
+
+type Handler struct {
+	logger *slog.Logger
+
+	registerService *application.RegisterService
+	loginService    *application.LoginService
+	sessionService  *application.SessionService
+
+	confirmationService *application.EmailConfirmationService
+	resetService        *application.PasswordResetService
+
+	passwordChangeService *application.PasswordChangeService
+
+	catalog *i18n.Catalog
+
+	// introspectionClients maps the ids of clients allowed to introspect
+	// tokens to their secrets.
+	introspectionClients map[string]string
+
+	mux *http.ServeMux
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+func NewHandler(
+	logger *slog.Logger,
+	registerService *application.RegisterService,
+	loginService *application.LoginService,
+	sessionService *application.SessionService,
+	confirmationService *application.EmailConfirmationService,
+	resetService *application.PasswordResetService,
+	passwordChangeService *application.PasswordChangeService,
+	catalog *i18n.Catalog,
+	introspectionClients map[string]string,
+) *Handler {
+	h := &Handler{
+		logger:                logger,
+		registerService:       registerService,
+		loginService:          loginService,
+		sessionService:        sessionService,
+		confirmationService:   confirmationService,
+		resetService:          resetService,
+		passwordChangeService: passwordChangeService,
+		catalog:               catalog,
+		introspectionClients:  introspectionClients,
+		mux:                   http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("POST /users", h.register)
+	h.mux.HandleFunc("PUT /users/me/password", h.changePassword)
+	h.mux.HandleFunc("POST /email-confirmations", h.resendConfirmation)
+	h.mux.HandleFunc("POST /email-confirmations/confirm", h.confirmEmail)
+	h.mux.HandleFunc("POST /password-resets", h.requestPasswordReset)
+	h.mux.HandleFunc("POST /password-resets/confirm", h.resetPassword)
+	h.mux.HandleFunc("POST /sessions", h.login)
+	h.mux.HandleFunc("POST /sessions/access-token", h.refreshAccessToken)
+	h.mux.HandleFunc("POST /sessions/refresh", h.refreshSession)
+	h.mux.HandleFunc("POST /sessions/logout", h.logout)
+	h.mux.HandleFunc("GET /sessions", h.listSessions)
+	h.mux.HandleFunc("DELETE /sessions", h.revokeAllSessions)
+	h.mux.HandleFunc("DELETE /sessions/{id}", h.revokeSession)
+	h.mux.HandleFunc("GET /.well-known/jwks.json", h.jwks)
+	if len(introspectionClients) > 0 {
+		h.mux.HandleFunc("POST /introspect", h.introspect)
+	}
+
+	return h
+}
+
+// locale picks the locale of the response to r from its Accept-Language
+// header.
+func (h *Handler) locale(r *http.Request) string {
+	return h.catalog.Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
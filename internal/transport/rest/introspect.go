@@ -0,0 +1,98 @@
+package rest
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/maxdikun/users-api/internal/application"
+)
+
+var errClientUnauthorized = errors.New("client authentication failed")
+
+// introspectionResponse is an RFC 7662 introspection response. Inactive
+// tokens only carry the active member, so the response does not reveal why a
+// token was rejected.
+type introspectionResponse struct {
+	Active    bool     `json:"active"`
+	TokenType string   `json:"token_type,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
+	ClientId  string   `json:"client_id,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	SessionId string   `json:"sid,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  []string `json:"aud,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	Id        string   `json:"jti,omitempty"`
+}
+
+// introspect implements RFC 7662 token introspection for access tokens.
+// Unlike local verification it also reports tokens of logged out or revoked
+// sessions inactive. Refresh tokens are never reported active: they are only
+// meant to be presented to this service by their owner.
+func (h *Handler) introspect(w http.ResponseWriter, r *http.Request) {
+	if !h.authenticateClient(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="introspect"`)
+		h.fail(w, r, errClientUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	if err := r.ParseForm(); err != nil {
+		h.fail(w, r, errMalformedBody)
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		h.fail(w, r, errMalformedBody)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	accessToken, err := h.sessionService.VerifyActiveAccessToken(r.Context(), token)
+	if errors.Is(err, application.ErrInternal) {
+		h.fail(w, r, err)
+		return
+	}
+	if err != nil {
+		h.respond(w, r, http.StatusOK, introspectionResponse{Active: false})
+		return
+	}
+
+	username, _ := accessToken.Claims["preferred_username"].(string)
+
+	h.respond(w, r, http.StatusOK, introspectionResponse{
+		Active:    true,
+		TokenType: "Bearer",
+		Scope:     strings.Join(accessToken.Scopes, " "),
+		ClientId:  accessToken.ClientId,
+		Username:  username,
+		Subject:   accessToken.Subject.String(),
+		SessionId: accessToken.SessionId.String(),
+		Issuer:    accessToken.Issuer,
+		Audience:  accessToken.Audience,
+		IssuedAt:  accessToken.IssuedAt.Unix(),
+		NotBefore: accessToken.NotBefore.Unix(),
+		ExpiresAt: accessToken.ExpiresAt.Unix(),
+		Id:        accessToken.Id,
+	})
+}
+
+// authenticateClient checks the HTTP Basic credentials of the request
+// against the configured introspection clients.
+func (h *Handler) authenticateClient(r *http.Request) bool {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	expected, known := h.introspectionClients[id]
+	match := subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) == 1
+	return known && match
+}
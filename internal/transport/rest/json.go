@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+const maxBodySize = 1 << 20
+
+var errMalformedBody = errors.New("request body is malformed")
+
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		return fmt.Errorf("%w: %w", errMalformedBody, err)
+	}
+	return nil
+}
+
+func (h *Handler) respond(w http.ResponseWriter, r *http.Request, status int, body any) {
+	h.write(w, r, status, "application/json", body)
+}
+
+func (h *Handler) write(w http.ResponseWriter, r *http.Request, status int, contentType string, body any) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+
+	if body == nil {
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.WarnContext(r.Context(), "Failed to write response body", slog.Any("error", err))
+	}
+}
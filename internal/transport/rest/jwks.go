@@ -0,0 +1,10 @@
+package rest
+
+import "net/http"
+
+// jwks publishes the public keys access tokens are signed with so other
+// services can verify them without sharing a secret.
+func (h *Handler) jwks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	h.respond(w, r, http.StatusOK, h.sessionService.JWKS())
+}
@@ -0,0 +1,43 @@
+package rest
+
+import (
+	"net/http"
+)
+
+type requestPasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// requestPasswordReset always answers 202, whether or not a mail was sent, so
+// it cannot be used to probe for registered addresses.
+func (h *Handler) requestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req requestPasswordResetRequest
+	if err := h.decode(w, r, &req); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	h.resetService.RequestReset(r.Context(), req.Email, h.locale(r))
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) resetPassword(w http.ResponseWriter, r *http.Request) {
+	var req resetPasswordRequest
+	if err := h.decode(w, r, &req); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	if err := h.resetService.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,211 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxdikun/users-api/internal/application"
+	"github.com/maxdikun/users-api/internal/entities"
+)
+
+type loginRequest struct {
+	Login      string `json:"login"`
+	Password   string `json:"password"`
+	DeviceName string `json:"device_name"`
+}
+
+const (
+	maxDeviceNameLength = 255
+	maxUserAgentLength  = 1024
+)
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type tokenSetResponse struct {
+	AccessToken           string    `json:"access_token"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+type sessionResponse struct {
+	Id          string    `json:"id"`
+	DeviceName  string    `json:"device_name"`
+	UserAgent   string    `json:"user_agent"`
+	IP          string    `json:"ip"`
+	CreatedAt   time.Time `json:"created_at"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type sessionListResponse struct {
+	Sessions []sessionResponse `json:"sessions"`
+}
+
+type accessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+func (h *Handler) login(w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+	if err := h.decode(w, r, &req); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	device := entities.Device{
+		Name:      truncate(req.DeviceName, maxDeviceNameLength),
+		UserAgent: truncate(r.UserAgent(), maxUserAgentLength),
+		IP:        clientIP(r),
+	}
+
+	tokens, err := h.loginService.Login(r.Context(), req.Login, req.Password, device)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	h.respond(w, r, http.StatusCreated, newTokenSetResponse(tokens))
+}
+
+func (h *Handler) refreshAccessToken(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := h.decode(w, r, &req); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	if req.RefreshToken == "" {
+		h.fail(w, r, application.ErrInvalidToken)
+		return
+	}
+
+	accessToken, err := h.sessionService.RefreshAccessToken(r.Context(), req.RefreshToken)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	h.respond(w, r, http.StatusOK, accessTokenResponse{AccessToken: accessToken})
+}
+
+func (h *Handler) refreshSession(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := h.decode(w, r, &req); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	if req.RefreshToken == "" {
+		h.fail(w, r, application.ErrInvalidToken)
+		return
+	}
+
+	tokens, err := h.sessionService.RefreshSession(r.Context(), req.RefreshToken)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	h.respond(w, r, http.StatusOK, newTokenSetResponse(tokens))
+}
+
+func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := h.decode(w, r, &req); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	if req.RefreshToken == "" {
+		h.fail(w, r, application.ErrInvalidToken)
+		return
+	}
+
+	if err := h.sessionService.Logout(r.Context(), req.RefreshToken); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) listSessions(w http.ResponseWriter, r *http.Request) {
+	user, err := h.authenticate(r)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(r.Context(), user)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	res := sessionListResponse{Sessions: make([]sessionResponse, 0, len(sessions))}
+	for _, session := range sessions {
+		res.Sessions = append(res.Sessions, newSessionResponse(session))
+	}
+
+	h.respond(w, r, http.StatusOK, res)
+}
+
+func (h *Handler) revokeSession(w http.ResponseWriter, r *http.Request) {
+	user, err := h.authenticate(r)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	session, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.fail(w, r, application.ErrSessionNotFound)
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(r.Context(), user, session); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) revokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	user, err := h.authenticate(r)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	if err := h.sessionService.RevokeAllSessions(r.Context(), user); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newSessionResponse(session entities.Session) sessionResponse {
+	return sessionResponse{
+		Id:          session.Id().String(),
+		DeviceName:  session.Device().Name,
+		UserAgent:   session.Device().UserAgent,
+		IP:          session.Device().IP,
+		CreatedAt:   session.CreatedAt(),
+		RefreshedAt: session.RefreshedAt(),
+		ExpiresAt:   session.ExpiresAt(),
+	}
+}
+
+func newTokenSetResponse(tokens application.TokenSet) tokenSetResponse {
+	return tokenSetResponse{
+		AccessToken:           tokens.Access,
+		RefreshToken:          tokens.Refresh,
+		RefreshTokenExpiresAt: tokens.RefreshExpiresAt,
+	}
+}
@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"net/http"
+)
+
+type registerRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (h *Handler) register(w http.ResponseWriter, r *http.Request) {
+	var req registerRequest
+	if err := h.decode(w, r, &req); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	if err := h.registerService.Register(r.Context(), req.Username, req.Password, req.Email, h.locale(r)); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	h.respond(w, r, http.StatusCreated, nil)
+}
+
+type changePasswordRequest struct {
+	CurrentPassword     string `json:"current_password"`
+	NewPassword         string `json:"new_password"`
+	RevokeOtherSessions bool   `json:"revoke_other_sessions"`
+}
+
+func (h *Handler) changePassword(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := h.accessToken(r)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	var req changePasswordRequest
+	if err := h.decode(w, r, &req); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	err = h.passwordChangeService.ChangePassword(
+		r.Context(),
+		accessToken.Subject,
+		accessToken.SessionId,
+		req.CurrentPassword,
+		req.NewPassword,
+		req.RevokeOtherSessions,
+	)
+	if err != nil {
+		h.fail(w, r, renameField(err, "password", "new_password"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,54 @@
+package tokenauth
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Claims is the content of a verified access token.
+type Claims struct {
+	ID        string
+	Subject   string
+	SessionID string
+	Issuer    string
+	Audience  []string
+	ClientID  string
+	Scopes    []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	// Raw holds every claim of the token, including custom ones such as
+	// preferred_username.
+	Raw map[string]any
+}
+
+// HasScopes reports whether the token was granted every one of scopes.
+func (c *Claims) HasScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(c.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+type claimsKey struct{}
+
+// NewContext returns a copy of ctx carrying claims.
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext returns the claims of the authenticated caller, if any.
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// Subject returns the id of the authenticated user, or an empty string.
+func Subject(ctx context.Context) string {
+	if claims, ok := FromContext(ctx); ok {
+		return claims.Subject
+	}
+	return ""
+}
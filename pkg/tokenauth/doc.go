@@ -0,0 +1,19 @@
+// Package tokenauth verifies access tokens issued by the users API in other
+// Go services.
+//
+// A Verifier checks a token's signature against a KeySource, either the
+// API's JWKS endpoint or locally configured public keys, along with its type,
+// validity window, issuer and audience:
+//
+//	keys := tokenauth.NewJWKS(tokenauth.JWKSConfig{URL: "https://users.example.com/.well-known/jwks.json"})
+//	verifier := tokenauth.NewVerifier(keys, tokenauth.VerifierConfig{
+//		Issuer:   "users-api",
+//		Audience: "orders",
+//	})
+//
+//	mux.Handle("GET /orders", tokenauth.RequireScopes("orders:read")(listOrders))
+//	http.ListenAndServe(":8080", tokenauth.Middleware(verifier)(mux))
+//
+// Handlers behind the middleware read the caller with FromContext. Package
+// grpcauth provides the same for gRPC servers.
+package tokenauth
@@ -0,0 +1,99 @@
+// Package grpcauth authenticates gRPC calls with access tokens issued by the
+// users API, see package tokenauth.
+package grpcauth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/maxdikun/users-api/pkg/tokenauth"
+)
+
+// Config configures the interceptors.
+type Config struct {
+	// Scopes lists the scopes required per full method name, such as
+	// "/orders.v1.Orders/ListOrders".
+	Scopes map[string][]string
+	// Public lists full method names that may be called without a token,
+	// such as health checks.
+	Public []string
+}
+
+// UnaryServerInterceptor authenticates unary calls with the bearer token of
+// the "authorization" metadata and puts the token's claims on the context.
+func UnaryServerInterceptor(verifier *tokenauth.Verifier, config Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, verifier, config, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor.
+func StreamServerInterceptor(verifier *tokenauth.Verifier, config Config) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(stream.Context(), verifier, config, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, verifier *tokenauth.Verifier, config Config, method string) (context.Context, error) {
+	for _, public := range config.Public {
+		if public == method {
+			return ctx, nil
+		}
+	}
+
+	token, ok := bearerToken(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "a bearer access token is required")
+	}
+
+	claims, err := verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "the access token is invalid")
+	}
+
+	if !claims.HasScopes(config.Scopes[method]...) {
+		return nil, status.Error(codes.PermissionDenied, "the access token lacks a required scope")
+	}
+
+	return tokenauth.NewContext(ctx, claims), nil
+}
+
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	for _, value := range md.Get("authorization") {
+		scheme, token, ok := strings.Cut(value, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") && token != "" {
+			return token, true
+		}
+	}
+	return "", false
+}
+
+// authenticatedStream overrides the context of a stream with one carrying the
+// caller's claims.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
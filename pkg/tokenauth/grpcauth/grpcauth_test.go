@@ -0,0 +1,143 @@
+package grpcauth_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/maxdikun/users-api/pkg/tokenauth"
+	"github.com/maxdikun/users-api/pkg/tokenauth/grpcauth"
+)
+
+const (
+	listOrders  = "/orders.v1.Orders/ListOrders"
+	watchOrders = "/orders.v1.Orders/WatchOrders"
+	healthCheck = "/grpc.health.v1.Health/Check"
+)
+
+// newVerifier returns a verifier along with a function signing tokens it
+// accepts with the given scope claim.
+func newVerifier(t *testing.T) (*tokenauth.Verifier, func(scope string) string) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	key, err := tokenauth.NewPublicKey("key-1", public)
+	if err != nil {
+		t.Fatalf("NewPublicKey() error = %v", err)
+	}
+
+	sign := func(scope string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{
+			"sub":   "5b1c6a4e-3f0e-4b43-9d51-0c4f6f1c2b7a",
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"scope": scope,
+		})
+		token.Header["typ"] = "at+jwt"
+		token.Header["kid"] = "key-1"
+		signed, err := token.SignedString(private)
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		return signed
+	}
+
+	return tokenauth.NewVerifier(tokenauth.StaticKeys{key}, tokenauth.VerifierConfig{}), sign
+}
+
+func TestInterceptors(t *testing.T) {
+	verifier, sign := newVerifier(t)
+	config := grpcauth.Config{
+		Scopes: map[string][]string{listOrders: {"orders:read"}, watchOrders: {"orders:read"}},
+		Public: []string{healthCheck},
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		wantCode      codes.Code
+		wantSubject   bool
+	}{
+		{name: "public method", method: healthCheck, wantCode: codes.OK},
+		{name: "no token", method: listOrders, wantCode: codes.Unauthenticated},
+		{name: "other scheme", method: listOrders, authorization: "Basic YWxpY2U6c2VjcmV0", wantCode: codes.Unauthenticated},
+		{name: "invalid token", method: listOrders, authorization: "Bearer not.a.token", wantCode: codes.Unauthenticated},
+		{name: "missing scope", method: listOrders, authorization: "Bearer " + sign("orders:write"), wantCode: codes.PermissionDenied},
+		{
+			name:          "valid token",
+			method:        listOrders,
+			authorization: "Bearer " + sign("orders:read orders:write"),
+			wantCode:      codes.OK,
+			wantSubject:   true,
+		},
+		{
+			name:          "method without scopes",
+			method:        "/orders.v1.Orders/GetOrder",
+			authorization: "Bearer " + sign(""),
+			wantCode:      codes.OK,
+			wantSubject:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.authorization != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.authorization))
+		}
+
+		t.Run("unary "+tt.name, func(t *testing.T) {
+			var subject string
+			interceptor := grpcauth.UnaryServerInterceptor(verifier, config)
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method},
+				func(ctx context.Context, _ any) (any, error) {
+					subject = tokenauth.Subject(ctx)
+					return nil, nil
+				})
+
+			if got := status.Code(err); got != tt.wantCode {
+				t.Errorf("code = %v, want %v", got, tt.wantCode)
+			}
+			if (subject != "") != tt.wantSubject {
+				t.Errorf("subject = %q, want subject %v", subject, tt.wantSubject)
+			}
+		})
+
+		t.Run("stream "+tt.name, func(t *testing.T) {
+			var subject string
+			interceptor := grpcauth.StreamServerInterceptor(verifier, config)
+			err := interceptor(nil, serverStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: tt.method},
+				func(_ any, stream grpc.ServerStream) error {
+					subject = tokenauth.Subject(stream.Context())
+					return nil
+				})
+
+			if got := status.Code(err); got != tt.wantCode {
+				t.Errorf("code = %v, want %v", got, tt.wantCode)
+			}
+			if (subject != "") != tt.wantSubject {
+				t.Errorf("subject = %q, want subject %v", subject, tt.wantSubject)
+			}
+		})
+	}
+}
+
+// serverStream is a grpc.ServerStream with only a context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,121 @@
+package tokenauth_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/maxdikun/users-api/pkg/tokenauth"
+)
+
+const (
+	testIssuer   = "users-api"
+	testAudience = "orders"
+)
+
+// signer is a private key along with the public key tokenauth verifies its
+// tokens with.
+type signer struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  tokenauth.PublicKey
+}
+
+func newSigner(t *testing.T, id string, algorithm string) signer {
+	t.Helper()
+
+	var private crypto.Signer
+	var method jwt.SigningMethod
+	var err error
+	switch algorithm {
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+		method = jwt.SigningMethodRS256
+	case "ES256":
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		method = jwt.SigningMethodES256
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+		method = jwt.SigningMethodEdDSA
+	default:
+		t.Fatalf("unknown algorithm %s", algorithm)
+	}
+	if err != nil {
+		t.Fatalf("failed to generate %s key: %v", algorithm, err)
+	}
+
+	public, err := tokenauth.NewPublicKey(id, private.Public())
+	if err != nil {
+		t.Fatalf("NewPublicKey() error = %v", err)
+	}
+
+	return signer{id: id, method: method, private: private, public: public}
+}
+
+// validClaims are the claims of a token Verify accepts.
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":       testIssuer,
+		"sub":       "5b1c6a4e-3f0e-4b43-9d51-0c4f6f1c2b7a",
+		"aud":       []string{"users-api", testAudience},
+		"iat":       now.Unix(),
+		"nbf":       now.Unix(),
+		"exp":       now.Add(time.Minute).Unix(),
+		"jti":       "token-id",
+		"sid":       "session-id",
+		"client_id": "users-api",
+		"scope":     "orders:read orders:write",
+	}
+}
+
+func (s signer) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	return s.signWithHeader(t, claims, map[string]any{"typ": "at+jwt", "kid": s.id})
+}
+
+func (s signer) signWithHeader(t *testing.T, claims jwt.MapClaims, header map[string]any) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(s.method, claims)
+	token.Header = map[string]any{"alg": s.method.Alg()}
+	for name, value := range header {
+		token.Header[name] = value
+	}
+
+	signed, err := token.SignedString(s.private)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+// jwk renders the public key as a JSON Web Key.
+func (s signer) jwk() map[string]string {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch key := s.public.Key.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA", "use": "sig", "alg": "RS256", "kid": s.id,
+			"n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		return map[string]string{
+			"kty": "EC", "use": "sig", "alg": "ES256", "kid": s.id, "crv": "P-256",
+			"x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32))),
+		}
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "use": "sig", "alg": "EdDSA", "kid": s.id, "crv": "Ed25519", "x": b64(key)}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package tokenauth_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxdikun/users-api/pkg/tokenauth"
+)
+
+func TestMiddlewareAndRequireScopes(t *testing.T) {
+	s := newSigner(t, "key-1", "EdDSA")
+	verifier := tokenauth.NewVerifier(tokenauth.StaticKeys{s.public}, tokenauth.VerifierConfig{Issuer: testIssuer})
+
+	readOnly := validClaims()
+	readOnly["scope"] = "orders:read"
+
+	tests := []struct {
+		name          string
+		authorization string
+		scopes        []string
+		wantStatus    int
+		wantCode      string
+		wantChallenge string
+	}{
+		{
+			name:          "no token",
+			wantStatus:    http.StatusUnauthorized,
+			wantCode:      "missing_token",
+			wantChallenge: `Bearer`,
+		},
+		{
+			name:          "other scheme",
+			authorization: "Basic YWxpY2U6c2VjcmV0",
+			wantStatus:    http.StatusUnauthorized,
+			wantCode:      "missing_token",
+			wantChallenge: `Bearer`,
+		},
+		{
+			name:          "invalid token",
+			authorization: "Bearer not.a.token",
+			wantStatus:    http.StatusUnauthorized,
+			wantCode:      "invalid_token",
+			wantChallenge: `Bearer error="invalid_token"`,
+		},
+		{
+			name:          "valid token",
+			authorization: "Bearer " + s.sign(t, validClaims()),
+			scopes:        []string{"orders:read", "orders:write"},
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name:          "lowercase scheme",
+			authorization: "bearer " + s.sign(t, validClaims()),
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name:          "missing scope",
+			authorization: "Bearer " + s.sign(t, readOnly),
+			scopes:        []string{"orders:read", "orders:write"},
+			wantStatus:    http.StatusForbidden,
+			wantCode:      "insufficient_scope",
+			wantChallenge: `Bearer error="insufficient_scope", scope="orders:read orders:write"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subject string
+			handler := tokenauth.Middleware(verifier)(tokenauth.RequireScopes(tt.scopes...)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					subject = tokenauth.Subject(r.Context())
+				}),
+			))
+
+			r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if subject == "" {
+					t.Error("handler saw no subject")
+				}
+				return
+			}
+
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+			var body struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if body.Code != tt.wantCode || body.Message == "" {
+				t.Errorf("body = %+v, want code %q", body, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRequireScopesWithoutMiddleware(t *testing.T) {
+	handler := tokenauth.RequireScopes("orders:read")(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("handler called without claims")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil).WithContext(context.Background()))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,261 @@
+package tokenauth
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// JWKSConfig configures a JWKS key source.
+type JWKSConfig struct {
+	// URL is the JWKS endpoint, typically
+	// https://<users api>/.well-known/jwks.json.
+	URL string
+	// Client defaults to a client with a 10 second timeout.
+	Client *http.Client
+	// RefreshInterval is how long fetched keys are trusted before the set is
+	// fetched again. Defaults to 5 minutes.
+	RefreshInterval time.Duration
+	// MinRefreshInterval limits how often a token with an unknown kid can
+	// trigger a fetch, so forged tokens cannot flood the endpoint. Defaults
+	// to 30 seconds.
+	MinRefreshInterval time.Duration
+}
+
+// JWKS is a KeySource backed by a JWKS endpoint. Keys are cached and the set
+// is fetched again when it goes stale or a token names a key it does not
+// hold yet, which is how keys rotated on the issuer are picked up.
+//
+// A JWKS is safe for concurrent use.
+type JWKS struct {
+	config JWKSConfig
+
+	mu        sync.Mutex
+	keys      map[string]PublicKey
+	fetchedAt time.Time
+	triedAt   time.Time
+	// fetching is closed when the fetch in flight ends and is nil while there
+	// is none; fetchErr is the error of the last fetch.
+	fetching chan struct{}
+	fetchErr error
+}
+
+func NewJWKS(config JWKSConfig) *JWKS {
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = 5 * time.Minute
+	}
+	if config.MinRefreshInterval <= 0 {
+		config.MinRefreshInterval = 30 * time.Second
+	}
+
+	return &JWKS{config: config}
+}
+
+// Key returns the cached key, fetching the set first when it is stale or
+// lacks the key. The lock is not held while fetching, so lookups of cached
+// keys never wait for the endpoint, and concurrent lookups share one fetch.
+func (j *JWKS) Key(ctx context.Context, id string) (PublicKey, error) {
+	j.mu.Lock()
+	now := time.Now()
+	key, known := j.keys[id]
+	stale := now.Sub(j.fetchedAt) >= j.config.RefreshInterval
+	if known && !stale {
+		j.mu.Unlock()
+		return key, nil
+	}
+
+	done := j.fetching
+	if done == nil {
+		if now.Sub(j.triedAt) < j.config.MinRefreshInterval {
+			j.mu.Unlock()
+			if known {
+				return key, nil
+			}
+			return PublicKey{}, fmt.Errorf("%w %q", ErrUnknownKey, id)
+		}
+
+		j.triedAt = now
+		done = make(chan struct{})
+		j.fetching = done
+		go j.refresh(done)
+	}
+	j.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		if known {
+			return key, nil
+		}
+		return PublicKey{}, ctx.Err()
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	// Keys we have keep being served while the endpoint is unavailable.
+	if key, ok := j.keys[id]; ok {
+		return key, nil
+	}
+	if j.fetchErr != nil {
+		return PublicKey{}, j.fetchErr
+	}
+	return PublicKey{}, fmt.Errorf("%w %q", ErrUnknownKey, id)
+}
+
+// refresh fetches the set and replaces the cached keys, then closes done. It
+// is detached from the lookup that started it, so giving up on one lookup
+// does not fail the others waiting for the fetch; the client timeout bounds
+// it instead.
+func (j *JWKS) refresh(done chan struct{}) {
+	startedAt := time.Now()
+	keys, err := j.fetch(context.Background())
+
+	j.mu.Lock()
+	if err == nil {
+		j.keys = keys
+		j.fetchedAt = startedAt
+	}
+	j.fetchErr = err
+	j.fetching = nil
+	j.mu.Unlock()
+
+	close(done)
+}
+
+// fetch downloads the current set.
+func (j *JWKS) fetch(ctx context.Context) (map[string]PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.config.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := j.config.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: unexpected status %s", res.Status)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		// Skip keys we cannot use rather than failing the whole set.
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[key.ID] = key
+	}
+
+	return keys, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) publicKey() (PublicKey, error) {
+	var key PublicKey
+	var err error
+
+	switch {
+	case k.Kty == "RSA":
+		var n, e []byte
+		if n, err = decode(k.N); err != nil {
+			return PublicKey{}, err
+		}
+		if e, err = decode(k.E); err != nil {
+			return PublicKey{}, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return PublicKey{}, errors.New("RSA exponent out of range")
+		}
+		key, err = NewPublicKey(k.Kid, &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())})
+	case k.Kty == "EC" && k.Crv == "P-256":
+		var x, y []byte
+		if x, err = decode(k.X); err != nil {
+			return PublicKey{}, err
+		}
+		if y, err = decode(k.Y); err != nil {
+			return PublicKey{}, err
+		}
+		// Let crypto/ecdh reject points that are not on the curve.
+		point := append([]byte{4}, append(pad(x, 32), pad(y, 32)...)...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return PublicKey{}, err
+		}
+		key, err = NewPublicKey(k.Kid, &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		})
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		var x []byte
+		if x, err = decode(k.X); err != nil {
+			return PublicKey{}, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return PublicKey{}, errors.New("invalid Ed25519 key size")
+		}
+		key, err = NewPublicKey(k.Kid, ed25519.PublicKey(x))
+	default:
+		return PublicKey{}, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+	if err != nil {
+		return PublicKey{}, err
+	}
+
+	if k.Alg != "" && k.Alg != key.Algorithm {
+		return PublicKey{}, fmt.Errorf("key %q is not a %s key", k.Kid, k.Alg)
+	}
+	return key, nil
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+func pad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
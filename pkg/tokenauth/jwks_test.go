@@ -0,0 +1,210 @@
+package tokenauth_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxdikun/users-api/pkg/tokenauth"
+)
+
+// jwksServer serves the keys it holds as a JWKS and counts the requests.
+type jwksServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	keys     []map[string]string
+	status   int
+	requests atomic.Int32
+	// release, when set, holds every request until it is closed.
+	release chan struct{}
+}
+
+func newJWKSServer(t *testing.T, keys ...map[string]string) *jwksServer {
+	t.Helper()
+
+	s := &jwksServer{keys: keys, status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+
+		s.mu.Lock()
+		keys, status, release := s.keys, s.status, s.release
+		s.mu.Unlock()
+
+		if release != nil {
+			<-release
+		}
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(status int, keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.keys = status, keys
+}
+
+func (s *jwksServer) hold() chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.release = make(chan struct{})
+	return s.release
+}
+
+func TestJWKSParsesKeys(t *testing.T) {
+	rsaKey := newSigner(t, "rsa", "RS256")
+	ecKey := newSigner(t, "ec", "ES256")
+	edKey := newSigner(t, "ed", "EdDSA")
+
+	encryption := edKey.jwk()
+	encryption["kid"], encryption["use"] = "encryption", "enc"
+	mislabelled := ecKey.jwk()
+	mislabelled["kid"], mislabelled["alg"] = "mislabelled", "RS256"
+	offCurve := ecKey.jwk()
+	offCurve["kid"], offCurve["y"] = "off-curve", offCurve["x"]
+	unsupported := map[string]string{"kty": "oct", "kid": "symmetric", "k": "c2VjcmV0"}
+
+	server := newJWKSServer(t, rsaKey.jwk(), ecKey.jwk(), edKey.jwk(), encryption, mislabelled, offCurve, unsupported)
+	jwks := tokenauth.NewJWKS(tokenauth.JWKSConfig{URL: server.URL})
+
+	for _, s := range []signer{rsaKey, ecKey, edKey} {
+		key, err := jwks.Key(context.Background(), s.id)
+		if err != nil {
+			t.Errorf("Key(%q) error = %v", s.id, err)
+			continue
+		}
+		if key.Algorithm != s.public.Algorithm {
+			t.Errorf("Key(%q).Algorithm = %s, want %s", s.id, key.Algorithm, s.public.Algorithm)
+		}
+		verifier := tokenauth.NewVerifier(jwks, tokenauth.VerifierConfig{})
+		if _, err := verifier.Verify(context.Background(), s.sign(t, validClaims())); err != nil {
+			t.Errorf("Verify() with %s key error = %v", s.id, err)
+		}
+	}
+
+	for _, id := range []string{"encryption", "mislabelled", "off-curve", "symmetric"} {
+		if _, err := jwks.Key(context.Background(), id); !errors.Is(err, tokenauth.ErrUnknownKey) {
+			t.Errorf("Key(%q) error = %v, want ErrUnknownKey", id, err)
+		}
+	}
+}
+
+func TestJWKSFetchesUnknownKeys(t *testing.T) {
+	first := newSigner(t, "first", "EdDSA")
+	second := newSigner(t, "second", "EdDSA")
+
+	server := newJWKSServer(t, first.jwk())
+	jwks := tokenauth.NewJWKS(tokenauth.JWKSConfig{URL: server.URL, MinRefreshInterval: time.Nanosecond})
+
+	if _, err := jwks.Key(context.Background(), "first"); err != nil {
+		t.Fatalf("Key(first) error = %v", err)
+	}
+	if _, err := jwks.Key(context.Background(), "first"); err != nil {
+		t.Fatalf("cached Key(first) error = %v", err)
+	}
+	if got := server.requests.Load(); got != 1 {
+		t.Errorf("requests after cached lookup = %d, want 1", got)
+	}
+
+	server.set(http.StatusOK, first.jwk(), second.jwk())
+	if _, err := jwks.Key(context.Background(), "second"); err != nil {
+		t.Errorf("Key(second) after rotation error = %v", err)
+	}
+}
+
+func TestJWKSLimitsRefetches(t *testing.T) {
+	known := newSigner(t, "known", "EdDSA")
+	server := newJWKSServer(t, known.jwk())
+	jwks := tokenauth.NewJWKS(tokenauth.JWKSConfig{URL: server.URL, MinRefreshInterval: time.Hour})
+
+	for range 5 {
+		if _, err := jwks.Key(context.Background(), "forged"); !errors.Is(err, tokenauth.ErrUnknownKey) {
+			t.Errorf("Key(forged) error = %v, want ErrUnknownKey", err)
+		}
+	}
+	if got := server.requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestJWKSServesCachedKeysWhileEndpointFails(t *testing.T) {
+	known := newSigner(t, "known", "EdDSA")
+	server := newJWKSServer(t, known.jwk())
+	jwks := tokenauth.NewJWKS(tokenauth.JWKSConfig{
+		URL:                server.URL,
+		RefreshInterval:    time.Nanosecond,
+		MinRefreshInterval: time.Nanosecond,
+	})
+
+	if _, err := jwks.Key(context.Background(), "known"); err != nil {
+		t.Fatalf("Key() error = %v", err)
+	}
+
+	server.set(http.StatusServiceUnavailable)
+	if _, err := jwks.Key(context.Background(), "known"); err != nil {
+		t.Errorf("Key() with failing endpoint error = %v", err)
+	}
+	if _, err := jwks.Key(context.Background(), "unknown"); err == nil || errors.Is(err, tokenauth.ErrUnknownKey) {
+		t.Errorf("Key(unknown) with failing endpoint error = %v, want the fetch error", err)
+	}
+}
+
+func TestJWKSDoesNotHoldLookupsDuringFetch(t *testing.T) {
+	known := newSigner(t, "known", "EdDSA")
+	server := newJWKSServer(t, known.jwk())
+	jwks := tokenauth.NewJWKS(tokenauth.JWKSConfig{URL: server.URL, MinRefreshInterval: time.Nanosecond})
+
+	if _, err := jwks.Key(context.Background(), "known"); err != nil {
+		t.Fatalf("Key() error = %v", err)
+	}
+
+	release := server.hold()
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = jwks.Key(context.Background(), "unknown")
+		}()
+	}
+	for server.requests.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A slow fetch for an unknown key must not hold up cached keys.
+	lookup := make(chan error, 1)
+	go func() {
+		_, err := jwks.Key(context.Background(), "known")
+		lookup <- err
+	}()
+	select {
+	case err := <-lookup:
+		if err != nil {
+			t.Errorf("Key(known) error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Key(known) waited for the fetch in flight")
+	}
+
+	// Lookups giving up do not wait for the fetch either.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := jwks.Key(ctx, "unknown"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Key() with expired context error = %v, want DeadlineExceeded", err)
+	}
+
+	close(release)
+	wg.Wait()
+	if got := server.requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want concurrent lookups to share 1 fetch", got-1)
+	}
+}
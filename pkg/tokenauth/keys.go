@@ -0,0 +1,78 @@
+package tokenauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// KeySource resolves the public key a token was signed with from the "kid"
+// header of the token.
+type KeySource interface {
+	Key(ctx context.Context, id string) (PublicKey, error)
+}
+
+// PublicKey is a key tokens are verified with.
+type PublicKey struct {
+	ID        string
+	Algorithm string
+	Key       crypto.PublicKey
+}
+
+// NewPublicKey wraps key, inferring the algorithm from its type.
+func NewPublicKey(id string, key crypto.PublicKey) (PublicKey, error) {
+	var algorithm string
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		algorithm = "RS256"
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return PublicKey{}, errors.New("only P-256 ECDSA keys are supported")
+		}
+		algorithm = "ES256"
+	case ed25519.PublicKey:
+		algorithm = "EdDSA"
+	default:
+		return PublicKey{}, fmt.Errorf("unsupported public key %T", key)
+	}
+
+	return PublicKey{ID: id, Algorithm: algorithm, Key: key}, nil
+}
+
+// ParsePublicKeyPEM reads a PKIX ("PUBLIC KEY") PEM public key, as printed by
+// `openssl pkey -pubout`.
+func ParsePublicKeyPEM(id string, data []byte) (PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return PublicKey{}, errors.New("no PUBLIC KEY PEM block found")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return PublicKey{}, err
+	}
+
+	return NewPublicKey(id, key)
+}
+
+// StaticKeys is a fixed set of locally configured keys. A key without an ID
+// matches any token, which suits services that trust a single key.
+type StaticKeys []PublicKey
+
+func (s StaticKeys) Key(_ context.Context, id string) (PublicKey, error) {
+	for _, key := range s {
+		if key.ID == "" || key.ID == id {
+			return key, nil
+		}
+	}
+	return PublicKey{}, fmt.Errorf("%w %q", ErrUnknownKey, id)
+}
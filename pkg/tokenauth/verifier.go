@@ -0,0 +1,110 @@
+package tokenauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid access token")
+
+// VerifierConfig configures the checks a Verifier runs on top of the
+// signature.
+type VerifierConfig struct {
+	// Issuer is the expected "iss" of tokens, ACCESS_TOKEN_ISSUER of the
+	// users API.
+	Issuer string
+	// Audience is the identifier of this service, which must be one of the
+	// token's "aud" values.
+	Audience string
+	// Leeway tolerates clock skew when checking the validity window.
+	Leeway time.Duration
+}
+
+// Verifier checks access tokens issued by the users API.
+type Verifier struct {
+	keys   KeySource
+	config VerifierConfig
+}
+
+func NewVerifier(keys KeySource, config VerifierConfig) *Verifier {
+	return &Verifier{keys: keys, config: config}
+}
+
+// Verify checks the signature, type, validity window, issuer and audience of
+// an access token and returns its claims. Every failure wraps
+// ErrInvalidToken.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "ES256", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(v.config.Leeway),
+	}
+	if v.config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(v.config.Issuer))
+	}
+	if v.config.Audience != "" {
+		options = append(options, jwt.WithAudience(v.config.Audience))
+	}
+
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (any, error) {
+		id, _ := token.Header["kid"].(string)
+
+		key, err := v.keys.Key(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if key.Algorithm != token.Method.Alg() {
+			return nil, fmt.Errorf("key %q does not sign %s", id, token.Method.Alg())
+		}
+		return key.Key, nil
+	}, options...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	// RFC 9068 access tokens are typed so other JWTs signed with the same keys,
+	// such as ID tokens, cannot be passed off as access tokens.
+	typ, _ := parsed.Header["typ"].(string)
+	if !strings.EqualFold(typ, "at+jwt") && !strings.EqualFold(typ, "application/at+jwt") {
+		return nil, fmt.Errorf("%w: unexpected token type %q", ErrInvalidToken, typ)
+	}
+
+	return newClaims(parsed.Claims.(jwt.MapClaims))
+}
+
+func newClaims(raw jwt.MapClaims) (*Claims, error) {
+	claims := &Claims{Raw: raw}
+
+	claims.ID, _ = raw["jti"].(string)
+	claims.SessionID, _ = raw["sid"].(string)
+	claims.Issuer, _ = raw["iss"].(string)
+	claims.ClientID, _ = raw["client_id"].(string)
+
+	subject, err := raw.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+	claims.Subject = subject
+
+	if claims.Audience, err = raw.GetAudience(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	scope, _ := raw["scope"].(string)
+	claims.Scopes = strings.Fields(scope)
+
+	if issuedAt, err := raw.GetIssuedAt(); err == nil && issuedAt != nil {
+		claims.IssuedAt = issuedAt.Time
+	}
+	if expiresAt, err := raw.GetExpirationTime(); err == nil && expiresAt != nil {
+		claims.ExpiresAt = expiresAt.Time
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,121 @@
+package tokenauth_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/maxdikun/users-api/pkg/tokenauth"
+)
+
+func TestVerifierAcceptsEveryAlgorithm(t *testing.T) {
+	for _, algorithm := range []string{"RS256", "ES256", "EdDSA"} {
+		t.Run(algorithm, func(t *testing.T) {
+			s := newSigner(t, "key-1", algorithm)
+			verifier := tokenauth.NewVerifier(
+				tokenauth.StaticKeys{s.public},
+				tokenauth.VerifierConfig{Issuer: testIssuer, Audience: testAudience},
+			)
+
+			claims, err := verifier.Verify(context.Background(), s.sign(t, validClaims()))
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			if claims.Subject != "5b1c6a4e-3f0e-4b43-9d51-0c4f6f1c2b7a" {
+				t.Errorf("Subject = %q", claims.Subject)
+			}
+			if claims.ID != "token-id" || claims.SessionID != "session-id" || claims.ClientID != "users-api" {
+				t.Errorf("ID, SessionID, ClientID = %q, %q, %q", claims.ID, claims.SessionID, claims.ClientID)
+			}
+			if claims.Issuer != testIssuer || !slices.Equal(claims.Audience, []string{"users-api", testAudience}) {
+				t.Errorf("Issuer, Audience = %q, %v", claims.Issuer, claims.Audience)
+			}
+			if !slices.Equal(claims.Scopes, []string{"orders:read", "orders:write"}) {
+				t.Errorf("Scopes = %v", claims.Scopes)
+			}
+			if claims.ExpiresAt.IsZero() || claims.IssuedAt.IsZero() {
+				t.Errorf("IssuedAt, ExpiresAt = %v, %v", claims.IssuedAt, claims.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestVerifierRejects(t *testing.T) {
+	s := newSigner(t, "key-1", "ES256")
+	other := newSigner(t, "key-2", "ES256")
+	rsaKey := newSigner(t, "key-1", "RS256")
+
+	with := func(name string, value any) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired", s.sign(t, with("exp", time.Now().Add(-time.Minute).Unix()))},
+		{"without expiry", s.sign(t, with("exp", nil))},
+		{"not yet valid", s.sign(t, with("nbf", time.Now().Add(time.Hour).Unix()))},
+		{"other issuer", s.sign(t, with("iss", "someone-else"))},
+		{"other audience", s.sign(t, with("aud", []string{"billing"}))},
+		{"without subject", s.sign(t, with("sub", nil))},
+		{"untyped", s.signWithHeader(t, validClaims(), map[string]any{"kid": s.id})},
+		{"id token", s.signWithHeader(t, validClaims(), map[string]any{"kid": s.id, "typ": "JWT"})},
+		{"unknown key", other.sign(t, validClaims())},
+		{"key of another algorithm", rsaKey.sign(t, validClaims())},
+		{"wrong signature", other.signWithHeader(t, validClaims(), map[string]any{"kid": s.id, "typ": "at+jwt"})},
+		{"garbage", "not.a.token"},
+	}
+
+	verifier := tokenauth.NewVerifier(
+		tokenauth.StaticKeys{s.public},
+		tokenauth.VerifierConfig{Issuer: testIssuer, Audience: testAudience},
+	)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), tt.token)
+			if !errors.Is(err, tokenauth.ErrInvalidToken) {
+				t.Errorf("Verify() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifierLeeway(t *testing.T) {
+	s := newSigner(t, "key-1", "EdDSA")
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-10 * time.Second).Unix()
+	token := s.sign(t, claims)
+
+	strict := tokenauth.NewVerifier(tokenauth.StaticKeys{s.public}, tokenauth.VerifierConfig{})
+	if _, err := strict.Verify(context.Background(), token); err == nil {
+		t.Error("Verify() without leeway accepted an expired token")
+	}
+
+	lenient := tokenauth.NewVerifier(tokenauth.StaticKeys{s.public}, tokenauth.VerifierConfig{Leeway: time.Minute})
+	if _, err := lenient.Verify(context.Background(), token); err != nil {
+		t.Errorf("Verify() with leeway error = %v", err)
+	}
+}
+
+func TestStaticKeysWithoutIdMatchAnyToken(t *testing.T) {
+	s := newSigner(t, "key-1", "EdDSA")
+	anonymous := s.public
+	anonymous.ID = ""
+
+	verifier := tokenauth.NewVerifier(tokenauth.StaticKeys{anonymous}, tokenauth.VerifierConfig{})
+	if _, err := verifier.Verify(context.Background(), s.sign(t, validClaims())); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}